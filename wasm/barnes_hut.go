@@ -0,0 +1,114 @@
+package main
+
+import "math"
+
+// barnesHutActive reports whether cohesion should use the Barnes–Hut approximation
+func barnesHutActive() bool {
+	return params.BarnesHutRadius > 0 && params.CohesionRadius >= params.BarnesHutRadius
+}
+
+// rebuildCohesionTree refreshes the center-of-mass tree used by approximate
+// cohesion, sharing the spatial index when it already is a quadtree
+func rebuildCohesionTree() {
+	if !barnesHutActive() {
+		cohesionTree = nil
+		return
+	}
+	if qt, ok := spatialGrid.(*Quadtree); ok {
+		cohesionTree = qt
+		return
+	}
+
+	if cohesionTree == nil {
+		cohesionTree = NewQuadtree()
+	}
+	cohesionTree.Clear()
+	for i := range boids {
+		cohesionTree.Insert(i, flock.Position(i))
+	}
+	cohesionTree.Build()
+}
+
+// barnesHutCenter approximates the center of mass of the boids within radius
+// of position, excluding the boid at self. Nodes entirely inside the radius
+// contribute their exact aggregate; nodes whose size over distance falls below
+// theta are treated as a single body at their center of mass.
+func (qt *Quadtree) barnesHutCenter(position Vector2, self int, radius, theta float64) (Vector2, int) {
+	qt.Build()
+	sum := Vector2{X: 0, Y: 0}
+	count := 0
+	if len(qt.nodes) == 0 {
+		return sum, 0
+	}
+
+	radiusSquared := radius * radius
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &qt.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		if boxDistanceSquared(position, node.minX, node.minY, node.maxX, node.maxY) >= radiusSquared {
+			continue // entirely outside
+		}
+
+		mass := node.end - node.start
+		containsSelf := position.X >= node.minX && position.X <= node.maxX && position.Y >= node.minY && position.Y <= node.maxY
+		if !containsSelf {
+			if boxFarthestSquared(position, node.minX, node.minY, node.maxX, node.maxY) < radiusSquared {
+				// Entirely inside: the aggregate is exact
+				sum = sum.Add(node.center.Mul(float64(mass)))
+				count += mass
+				continue
+			}
+
+			size := math.Max(node.maxX-node.minX, node.maxY-node.minY)
+			distanceSquared := position.DistanceSquared(node.center)
+			if size*size < theta*theta*distanceSquared {
+				// Far enough away: treat the node as one body at its center of mass
+				if distanceSquared < radiusSquared {
+					sum = sum.Add(node.center.Mul(float64(mass)))
+					count += mass
+				}
+				continue
+			}
+		}
+
+		if node.firstChild < 0 {
+			for _, index := range qt.order[node.start:node.end] {
+				if index == self {
+					continue
+				}
+				distanceSquared := qt.points[index].DistanceSquared(position)
+				if distanceSquared > 0 && distanceSquared < radiusSquared {
+					sum = sum.Add(qt.points[index])
+					count++
+				}
+			}
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			stack = append(stack, node.firstChild+c)
+		}
+	}
+
+	if count == 0 {
+		return sum, 0
+	}
+	return sum.Div(float64(count)), count
+}
+
+// cohesionBarnesHut is the approximate counterpart of cohesion
+func (b *Boid) cohesionBarnesHut(boidIndex int) Vector2 {
+	center, count := cohesionTree.barnesHutCenter(b.Position, boidIndex, params.CohesionRadius, params.BarnesHutTheta)
+	if count > 0 {
+		return b.seek(center).Mul(params.CohesionStrength)
+	}
+	return Vector2{X: 0, Y: 0}
+}
+
+// boxFarthestSquared returns the squared distance from p to the farthest corner of the box
+func boxFarthestSquared(p Vector2, minX, minY, maxX, maxY float64) float64 {
+	dx := math.Max(p.X-minX, maxX-p.X)
+	dy := math.Max(p.Y-minY, maxY-p.Y)
+	return dx*dx + dy*dy
+}
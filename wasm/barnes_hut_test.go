@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// setupCohesionFlock fills the world with a clustered flock and large-radius cohesion
+func setupCohesionFlock(theta float64) {
+	initSimulation(0, 800.0, 600.0)
+	positions := clusteredPositions(rand.New(rand.NewSource(11)), 600, 800.0, 600.0)
+	for _, p := range positions {
+		appendBoid(NewBoid(p.X, p.Y))
+	}
+
+	params = DefaultSimulationParams()
+	params.CohesionRadius = 400.0
+	params.BarnesHutRadius = 300.0
+	params.BarnesHutTheta = theta
+	rebuildSpatialGrid()
+}
+
+// exactCohesion computes cohesion with the approximation switched off
+func exactCohesion(boidIndex int) Vector2 {
+	saved := params.BarnesHutRadius
+	params.BarnesHutRadius = 0
+	defer func() { params.BarnesHutRadius = saved }()
+	b := boidAt(boidIndex)
+	return b.cohesion(boidIndex, nil)
+}
+
+func TestBarnesHutZeroThetaIsExact(t *testing.T) {
+	setupCohesionFlock(0)
+
+	for i := 0; i < len(boids); i += 7 {
+		b := boidAt(i)
+		approx := b.cohesion(i, nil)
+		exact := exactCohesion(i)
+		if approx.Sub(exact).Magnitude() > 1e-9 {
+			t.Fatalf("boid %d cohesion with theta 0 = %v, want %v", i, approx, exact)
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	cohesionTree = nil
+	params = SimulationParams{}
+}
+
+func TestBarnesHutErrorBound(t *testing.T) {
+	for _, theta := range []float64{0.3, 0.5, 1.0} {
+		setupCohesionFlock(theta)
+
+		// Compare the approximate cohesion force with the exact one, relative
+		// to the largest force the rule can produce. Boids sitting on their
+		// own center of mass flip direction on any error, so bound the bulk
+		// of the flock rather than the worst boid.
+		scale := defaultMaxForce * params.CohesionStrength
+		errs := make([]float64, len(boids))
+		totalError := 0.0
+		for i := range boids {
+			b := boidAt(i)
+			errs[i] = b.cohesion(i, nil).Distance(exactCohesion(i)) / scale
+			totalError += errs[i]
+		}
+		sort.Float64s(errs)
+		meanError := totalError / float64(len(errs))
+		p90Error := errs[len(errs)*9/10]
+
+		if meanError > 0.2*theta {
+			t.Errorf("theta %v mean relative force error = %v, want <= %v", theta, meanError, 0.2*theta)
+		}
+		if p90Error > 0.6*theta {
+			t.Errorf("theta %v 90th percentile relative force error = %v, want <= %v", theta, p90Error, 0.6*theta)
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	cohesionTree = nil
+	params = SimulationParams{}
+}
+
+func TestBarnesHutSwitchOver(t *testing.T) {
+	setupCohesionFlock(0.5)
+	if !barnesHutActive() || cohesionTree == nil {
+		t.Fatalf("Barnes–Hut inactive with cohesion radius %v >= %v", params.CohesionRadius, params.BarnesHutRadius)
+	}
+
+	// Below the switch-over radius the exact scan is used and no tree is kept
+	params.CohesionRadius = 100.0
+	rebuildSpatialGrid()
+	if barnesHutActive() || cohesionTree != nil {
+		t.Errorf("Barnes–Hut active with cohesion radius %v < %v", params.CohesionRadius, params.BarnesHutRadius)
+	}
+
+	// A quadtree spatial index doubles as the center-of-mass tree
+	params.CohesionRadius = 400.0
+	changeSpatialIndex(IndexQuadtree)
+	if cohesionTree != spatialGrid {
+		t.Errorf("cohesion tree not shared with the quadtree spatial index")
+	}
+
+	// Reset global state
+	spatialIndexKind = IndexGrid
+	loadBoids(nil)
+	spatialGrid = nil
+	cohesionTree = nil
+	params = SimulationParams{}
+}
@@ -2,29 +2,70 @@ package main
 
 import "math/rand"
 
-// Boid represents a single boid entity
+// Boid represents a single boid entity. Inside the engine its position and
+// velocity live in flock and the rest in boids; a Boid is the complete view
+// of one boid, used to spawn it, export it and exchange it between domains.
 type Boid struct {
+	ID           int // Stable identifier, unaffected by the boid's slice index
 	Position     Vector2
 	Velocity     Vector2
 	Acceleration Vector2
 	MaxSpeed     float64
 	MaxForce     float64
+	Steering     Vector2 // Last computed flocking force, reused when a step runs out of budget
+	Cluster      int     // Flock label from the last cluster detection, -1 for none
+	Flock        int     // Persistent flock ID tracked across detections, -1 for none
+}
+
+// boidAttrs holds the per-boid fields that are not in flock, index-aligned
+// with its arrays
+type boidAttrs struct {
+	ID           int
+	Acceleration Vector2
+	MaxSpeed     float64
+	MaxForce     float64
+	Steering     Vector2
+	Cluster      int
+	Flock        int
+}
+
+// attrs returns the fields of b that the boids slice stores
+func (b *Boid) attrs() boidAttrs {
+	return boidAttrs{
+		ID:           b.ID,
+		Acceleration: b.Acceleration,
+		MaxSpeed:     b.MaxSpeed,
+		MaxForce:     b.MaxForce,
+		Steering:     b.Steering,
+		Cluster:      b.Cluster,
+		Flock:        b.Flock,
+	}
 }
 
 // NewBoid creates a new boid at the specified position
 func NewBoid(x, y float64) Boid {
 	return Boid{
+		ID:       allocateBoidID(),
 		Position: Vector2{X: x, Y: y},
 		Velocity: Vector2{
 			X: (rand.Float64() - 0.5) * 2.0,
 			Y: (rand.Float64() - 0.5) * 2.0,
 		},
 		Acceleration: Vector2{X: 0, Y: 0},
-		MaxSpeed:     2.0,
-		MaxForce:     0.03,
+		MaxSpeed:     defaultMaxSpeed,
+		MaxForce:     defaultMaxForce,
+		Cluster:      noCluster,
+		Flock:        noCluster,
 	}
 }
 
+// allocateBoidID returns the next unused boid ID
+func allocateBoidID() int {
+	id := nextBoidID
+	nextBoidID++
+	return id
+}
+
 // Update updates the boid's position and velocity
 func (b *Boid) Update() {
 	// Update velocity by acceleration
@@ -0,0 +1,210 @@
+package main
+
+import "fmt"
+
+// Precision selects the floating-point width of boid positions and velocities
+type Precision string
+
+const (
+	Precision64 Precision = "float64"
+	Precision32 Precision = "float32" // state is stored as float32 and exported as-is
+)
+
+// BoidArrays is the structure-of-arrays storage of the flock's positions and
+// velocities, and the only place the engine keeps them. Neighbor loops read
+// it directly, and its arrays are handed to JavaScript without conversion.
+// Only the arrays of the active precision are allocated.
+type BoidArrays struct {
+	narrow bool // float32 precision: the float32 arrays are in use
+
+	X, Y   []float64
+	VX, VY []float64
+
+	X32, Y32   []float32
+	VX32, VY32 []float32
+}
+
+// newBoidArrays creates empty storage of the given precision
+func newBoidArrays(p Precision) BoidArrays {
+	return BoidArrays{narrow: p == Precision32}
+}
+
+// Len returns the number of boids in the arrays
+func (a *BoidArrays) Len() int {
+	if a.narrow {
+		return len(a.X32)
+	}
+	return len(a.X)
+}
+
+// Position returns the position of boid i
+func (a *BoidArrays) Position(i int) Vector2 {
+	if a.narrow {
+		return Vector2{X: float64(a.X32[i]), Y: float64(a.Y32[i])}
+	}
+	return Vector2{X: a.X[i], Y: a.Y[i]}
+}
+
+// Velocity returns the velocity of boid i
+func (a *BoidArrays) Velocity(i int) Vector2 {
+	if a.narrow {
+		return Vector2{X: float64(a.VX32[i]), Y: float64(a.VY32[i])}
+	}
+	return Vector2{X: a.VX[i], Y: a.VY[i]}
+}
+
+// SetPosition stores the position of boid i, rounding it in float32 precision
+func (a *BoidArrays) SetPosition(i int, p Vector2) {
+	if a.narrow {
+		a.X32[i], a.Y32[i] = float32(p.X), float32(p.Y)
+		return
+	}
+	a.X[i], a.Y[i] = p.X, p.Y
+}
+
+// SetVelocity stores the velocity of boid i, rounding it in float32 precision
+func (a *BoidArrays) SetVelocity(i int, v Vector2) {
+	if a.narrow {
+		a.VX32[i], a.VY32[i] = float32(v.X), float32(v.Y)
+		return
+	}
+	a.VX[i], a.VY[i] = v.X, v.Y
+}
+
+// push appends a boid's position and velocity
+func (a *BoidArrays) push(p, v Vector2) {
+	if a.narrow {
+		a.X32, a.Y32 = append(a.X32, float32(p.X)), append(a.Y32, float32(p.Y))
+		a.VX32, a.VY32 = append(a.VX32, float32(v.X)), append(a.VY32, float32(v.Y))
+		return
+	}
+	a.X, a.Y = append(a.X, p.X), append(a.Y, p.Y)
+	a.VX, a.VY = append(a.VX, v.X), append(a.VY, v.Y)
+}
+
+// truncate keeps the first n boids
+func (a *BoidArrays) truncate(n int) {
+	if a.narrow {
+		a.X32, a.Y32, a.VX32, a.VY32 = a.X32[:n], a.Y32[:n], a.VX32[:n], a.VY32[:n]
+		return
+	}
+	a.X, a.Y, a.VX, a.VY = a.X[:n], a.Y[:n], a.VX[:n], a.VY[:n]
+}
+
+// move copies boid from over boid to, for compacting the arrays in place
+func (a *BoidArrays) move(to, from int) {
+	if a.narrow {
+		a.X32[to], a.Y32[to], a.VX32[to], a.VY32[to] = a.X32[from], a.Y32[from], a.VX32[from], a.VY32[from]
+		return
+	}
+	a.X[to], a.Y[to], a.VX[to], a.VY[to] = a.X[from], a.Y[from], a.VX[from], a.VY[from]
+}
+
+// permute reorders the arrays so the boid previously at order[i] ends up at i
+func (a *BoidArrays) permute(order []int) {
+	reordered := BoidArrays{narrow: a.narrow}
+	for _, from := range order {
+		reordered.push(a.Position(from), a.Velocity(from))
+	}
+	*a = reordered
+}
+
+// convert switches the arrays to precision p, rounding when narrowing
+func (a *BoidArrays) convert(p Precision) {
+	converted := newBoidArrays(p)
+	for i := 0; i < a.Len(); i++ {
+		converted.push(a.Position(i), a.Velocity(i))
+	}
+	*a = converted
+}
+
+// boidAt returns the complete boid at index i, combining its attributes
+// with its position and velocity
+func boidAt(i int) Boid {
+	b := boids[i]
+	return Boid{
+		ID:           b.ID,
+		Position:     flock.Position(i),
+		Velocity:     flock.Velocity(i),
+		Acceleration: b.Acceleration,
+		MaxSpeed:     b.MaxSpeed,
+		MaxForce:     b.MaxForce,
+		Steering:     b.Steering,
+		Cluster:      b.Cluster,
+		Flock:        b.Flock,
+	}
+}
+
+// storeBoid writes a complete boid back to index i
+func storeBoid(i int, b Boid) {
+	boids[i] = b.attrs()
+	flock.SetPosition(i, b.Position)
+	flock.SetVelocity(i, b.Velocity)
+	spatialGridStale = true
+}
+
+// appendBoid adds a boid to the end of the flock. Callers reindex.
+func appendBoid(b Boid) {
+	boids = append(boids, b.attrs())
+	flock.push(b.Position, b.Velocity)
+	spatialGridStale = true
+}
+
+// truncateBoids keeps the first n boids. Callers reindex.
+func truncateBoids(n int) {
+	boids = boids[:n]
+	flock.truncate(n)
+	spatialGridStale = true
+}
+
+// filterBoids keeps the boids for which keep returns true, in order, and
+// returns the others. Callers reindex.
+func filterBoids(keep func(i int) bool) []Boid {
+	var removed []Boid
+	kept := 0
+	for i := range boids {
+		if !keep(i) {
+			removed = append(removed, boidAt(i))
+			continue
+		}
+		boids[kept] = boids[i]
+		flock.move(kept, i)
+		kept++
+	}
+	truncateBoids(kept)
+	return removed
+}
+
+// loadBoids replaces the flock with list
+func loadBoids(list []Boid) {
+	boids = make([]boidAttrs, 0, len(list))
+	flock = newBoidArrays(precision)
+	spatialGridStale = true
+	for _, b := range list {
+		appendBoid(b)
+	}
+	reindexBoids()
+}
+
+// snapshotBoids returns a copy of every boid in the flock
+func snapshotBoids() []Boid {
+	list := make([]Boid, len(boids))
+	for i := range boids {
+		list[i] = boidAt(i)
+	}
+	return list
+}
+
+// changePrecision switches between float64 and float32 boid state
+func changePrecision(p Precision) error {
+	switch p {
+	case Precision64, Precision32:
+	default:
+		return fmt.Errorf("unknown precision %q", p)
+	}
+
+	precision = p
+	flock.convert(p)
+	spatialGridStale = true
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestBoidArraysHoldState(t *testing.T) {
+	precision = Precision64
+	loadBoids([]Boid{NewBoid(1.0, 2.0), NewBoid(3.0, 4.0)})
+	flock.SetVelocity(1, Vector2{X: -1.0, Y: 0.5})
+
+	if flock.Len() != 2 {
+		t.Fatalf("flock.Len() = %d, want 2", flock.Len())
+	}
+	if p := flock.Position(1); p != (Vector2{X: 3.0, Y: 4.0}) {
+		t.Errorf("flock.Position(1) = %v, want (3, 4)", p)
+	}
+	if b := boidAt(1); b.Velocity != (Vector2{X: -1.0, Y: 0.5}) || b.ID != boids[1].ID {
+		t.Errorf("boidAt(1) = %+v, want the stored velocity and id", b)
+	}
+	if flock.X32 != nil {
+		t.Errorf("float32 arrays allocated in float64 mode")
+	}
+
+	// Removing boids compacts the arrays with them
+	removed := filterBoids(func(i int) bool { return i != 0 })
+	if len(removed) != 1 || removed[0].Position != (Vector2{X: 1.0, Y: 2.0}) {
+		t.Errorf("filterBoids() removed %+v, want boid at (1, 2)", removed)
+	}
+	if flock.Len() != 1 || flock.Position(0) != (Vector2{X: 3.0, Y: 4.0}) {
+		t.Errorf("after filterBoids() len = %d, position = %v; want 1 and (3, 4)", flock.Len(), flock.Position(0))
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestChangePrecisionFloat32(t *testing.T) {
+	loadBoids([]Boid{NewBoid(0.1, 1.0/3.0)})
+
+	if err := changePrecision(Precision32); err != nil {
+		t.Fatalf("changePrecision(float32) error = %v", err)
+	}
+
+	// State moves to the float32 arrays, rounded, and the float64 arrays go away
+	if flock.Position(0).X != float64(float32(0.1)) {
+		t.Errorf("position X = %v, want float32-rounded %v", flock.Position(0).X, float64(float32(0.1)))
+	}
+	if flock.X32[0] != float32(0.1) || flock.Y32[0] != float32(1.0/3.0) {
+		t.Errorf("float32 arrays = (%v, %v), want (%v, %v)", flock.X32[0], flock.Y32[0], float32(0.1), float32(1.0/3.0))
+	}
+	if flock.X != nil {
+		t.Errorf("float64 arrays kept in float32 mode")
+	}
+
+	if err := changePrecision("float16"); err == nil {
+		t.Errorf("changePrecision() with unknown precision error = nil, want error")
+	}
+
+	// Reset global state
+	precision = Precision64
+	loadBoids(nil)
+}
+
+func TestStepSimulationFloat32(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulation(100, 800.0, 600.0)
+	changePrecision(Precision32)
+
+	for i := 0; i < 3; i++ {
+		stepSimulation()
+	}
+
+	for i := range boids {
+		p := flock.Position(i)
+		if p.X != float64(float32(p.X)) || p.Y != float64(float32(p.Y)) {
+			t.Fatalf("boid %d position %v not float32-representable", i, p)
+		}
+		if flock.X32[i] != float32(p.X) {
+			t.Fatalf("flock.X32[%d] = %v, want %v", i, flock.X32[i], float32(p.X))
+		}
+	}
+
+	// Reset global state
+	precision = Precision64
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
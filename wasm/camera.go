@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Camera zoom limits
+const (
+	minCameraZoom = 0.05
+	maxCameraZoom = 20.0
+)
+
+// Rect is an axis-aligned rectangle in world coordinates
+type Rect struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+// Contains reports whether the point lies inside the rectangle
+func (r Rect) Contains(p Vector2) bool {
+	return p.X >= r.X && p.X <= r.X+r.Width && p.Y >= r.Y && p.Y <= r.Y+r.Height
+}
+
+// Camera maps world coordinates onto the canvas
+type Camera struct {
+	X, Y   float64 // world position shown at the canvas's top-left corner
+	Zoom   float64 // canvas pixels per world unit
+	Width  float64 // canvas size in pixels
+	Height float64
+}
+
+// NewCamera creates an unzoomed camera for a canvas of the given size
+func NewCamera(width, height float64) Camera {
+	return Camera{Zoom: 1.0, Width: width, Height: height}
+}
+
+// ViewRect returns the part of the world currently shown on the canvas
+func (c Camera) ViewRect() Rect {
+	return Rect{
+		X:      c.X,
+		Y:      c.Y,
+		Width:  c.Width / c.Zoom,
+		Height: c.Height / c.Zoom,
+	}
+}
+
+// ScreenToWorld converts a canvas pixel position to world coordinates
+func (c Camera) ScreenToWorld(p Vector2) Vector2 {
+	return Vector2{X: c.X + p.X/c.Zoom, Y: c.Y + p.Y/c.Zoom}
+}
+
+// WorldToScreen converts a world position to canvas pixel coordinates
+func (c Camera) WorldToScreen(p Vector2) Vector2 {
+	return Vector2{X: (p.X - c.X) * c.Zoom, Y: (p.Y - c.Y) * c.Zoom}
+}
+
+// Pan moves the camera by a distance given in canvas pixels
+func (c *Camera) Pan(dx, dy float64) {
+	c.X += dx / c.Zoom
+	c.Y += dy / c.Zoom
+}
+
+// ZoomAt scales the zoom by factor while keeping the world point under the
+// given canvas position fixed on screen
+func (c *Camera) ZoomAt(factor float64, anchor Vector2) error {
+	if !(factor > 0) {
+		return fmt.Errorf("zoom factor must be positive, got %v", factor)
+	}
+
+	before := c.ScreenToWorld(anchor)
+	c.Zoom = math.Min(math.Max(c.Zoom*factor, minCameraZoom), maxCameraZoom)
+	after := c.ScreenToWorld(anchor)
+
+	c.X += before.X - after.X
+	c.Y += before.Y - after.Y
+	return nil
+}
+
+// Validate checks that the camera can map between canvas and world
+func (c Camera) Validate() error {
+	if c.Zoom < minCameraZoom || c.Zoom > maxCameraZoom {
+		return fmt.Errorf("camera zoom must be within [%v, %v], got %v", minCameraZoom, maxCameraZoom, c.Zoom)
+	}
+	if !(c.Width > 0) || !(c.Height > 0) {
+		return fmt.Errorf("canvas size must be positive, got %vx%v", c.Width, c.Height)
+	}
+	return nil
+}
+
+// visibleBoidIndices returns the indices of boids inside view, using the
+// spatial grid so the cost scales with the visible area
+func visibleBoidIndices(view Rect) []int {
+	ensureSpatialGrid()
+	candidates := spatialGrid.QueryRect(view)
+	visible := candidates[:0]
+	for _, index := range candidates {
+		if view.Contains(flock.Position(index)) {
+			visible = append(visible, index)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCameraScreenWorldRoundTrip(t *testing.T) {
+	c := NewCamera(800.0, 600.0)
+	c.X, c.Y, c.Zoom = 1000.0, 500.0, 2.0
+
+	world := c.ScreenToWorld(Vector2{X: 400.0, Y: 300.0})
+	expected := Vector2{X: 1200.0, Y: 650.0}
+	if world != expected {
+		t.Errorf("ScreenToWorld() = %v, want %v", world, expected)
+	}
+
+	screen := c.WorldToScreen(world)
+	if screen != (Vector2{X: 400.0, Y: 300.0}) {
+		t.Errorf("WorldToScreen() = %v, want (400, 300)", screen)
+	}
+
+	view := c.ViewRect()
+	if view != (Rect{X: 1000.0, Y: 500.0, Width: 400.0, Height: 300.0}) {
+		t.Errorf("ViewRect() = %v, want {1000 500 400 300}", view)
+	}
+}
+
+func TestCameraPanAndZoom(t *testing.T) {
+	c := NewCamera(800.0, 600.0)
+	c.Zoom = 2.0
+
+	// Panning is in canvas pixels, so it moves half as far in the world at 2x
+	c.Pan(100.0, -50.0)
+	if c.X != 50.0 || c.Y != -25.0 {
+		t.Errorf("after Pan camera = (%v, %v), want (50, -25)", c.X, c.Y)
+	}
+
+	anchor := Vector2{X: 200.0, Y: 100.0}
+	before := c.ScreenToWorld(anchor)
+	if err := c.ZoomAt(1.5, anchor); err != nil {
+		t.Fatalf("ZoomAt() error = %v", err)
+	}
+	after := c.ScreenToWorld(anchor)
+	if math.Abs(before.X-after.X) > 1e-9 || math.Abs(before.Y-after.Y) > 1e-9 {
+		t.Errorf("anchor moved from %v to %v while zooming", before, after)
+	}
+	if c.Zoom != 3.0 {
+		t.Errorf("Zoom = %v, want 3.0", c.Zoom)
+	}
+
+	// Zoom is clamped to the allowed range
+	c.ZoomAt(1000.0, anchor)
+	if c.Zoom != maxCameraZoom {
+		t.Errorf("Zoom = %v, want clamped to %v", c.Zoom, maxCameraZoom)
+	}
+	if err := c.ZoomAt(0, anchor); err == nil {
+		t.Errorf("ZoomAt(0) error = nil, want error")
+	}
+}
+
+func TestVisibleBoidIndices(t *testing.T) {
+	initSimulation(0, 4000.0, 3000.0)
+	loadBoids([]Boid{
+		NewBoid(100.0, 100.0),
+		NewBoid(500.0, 400.0),
+		NewBoid(2000.0, 1500.0),
+		NewBoid(3900.0, 2900.0),
+	})
+	rebuildSpatialGrid()
+
+	visible := visibleBoidIndices(Rect{X: 0, Y: 0, Width: 800.0, Height: 600.0})
+	if len(visible) != 2 || visible[0] != 0 || visible[1] != 1 {
+		t.Errorf("visibleBoidIndices() = %v, want [0 1]", visible)
+	}
+
+	// A view partly outside the world only returns boids inside it
+	visible = visibleBoidIndices(Rect{X: 3500.0, Y: 2500.0, Width: 800.0, Height: 600.0})
+	if len(visible) != 1 || visible[0] != 3 {
+		t.Errorf("visibleBoidIndices() at world edge = %v, want [3]", visible)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	worldWidth, worldHeight = 800.0, 600.0
+}
+
+func TestVisibleBoidIndicesAfterStep(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulation(0, 800.0, 600.0)
+	boid := NewBoid(799.0, 300.0)
+	boid.Velocity = Vector2{X: 3.0, Y: 0}
+	loadBoids([]Boid{boid})
+
+	// The step wraps the boid to the other side of the world
+	stepSimulation()
+	if p := flock.Position(0); p.X > 10.0 {
+		t.Fatalf("boid at %v, want wrapped to the left edge", p)
+	}
+	if visible := visibleBoidIndices(Rect{X: 0, Y: 250.0, Width: 100.0, Height: 100.0}); len(visible) != 1 {
+		t.Errorf("visibleBoidIndices() at the new position = %v, want [0]", visible)
+	}
+	if visible := visibleBoidIndices(Rect{X: 700.0, Y: 250.0, Width: 100.0, Height: 100.0}); len(visible) != 0 {
+		t.Errorf("visibleBoidIndices() at the old position = %v, want none", visible)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	stepCount = 0
+	params = SimulationParams{}
+}
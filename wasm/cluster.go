@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Cluster detection defaults
+const (
+	defaultClusterRadius  = 50.0 // boids closer than this belong to the same flock
+	defaultClusterMinSize = 3    // smaller groups are stragglers, not flocks
+)
+
+// noCluster is the label of boids that belong to no flock
+const noCluster = -1
+
+// ClusterOptions configures flock detection
+type ClusterOptions struct {
+	Radius   float64 // linking distance between boids of one flock
+	MinSize  int     // groups smaller than this are left unlabeled
+	Interval int     // steps between automatic detection, 0 detects only on request
+}
+
+// Validate checks that the options describe a usable clustering
+func (o ClusterOptions) Validate() error {
+	if math.IsNaN(o.Radius) || math.IsInf(o.Radius, 0) || o.Radius <= 0 {
+		return fmt.Errorf("cluster radius must be a positive finite number, got %v", o.Radius)
+	}
+	if o.MinSize < 1 {
+		return fmt.Errorf("cluster minimum size must be at least 1, got %d", o.MinSize)
+	}
+	if o.Interval < 0 {
+		return fmt.Errorf("cluster interval must not be negative, got %d", o.Interval)
+	}
+	return nil
+}
+
+// ClusterResult summarizes the flocks found by detectClusters
+type ClusterResult struct {
+	Step      int
+	Sizes     []int // boids per flock, indexed by label, largest first
+	Unlabeled int   // boids in groups below the minimum size
+}
+
+// Count is the number of flocks
+func (r ClusterResult) Count() int {
+	return len(r.Sizes)
+}
+
+var (
+	clusterOptions = ClusterOptions{Radius: defaultClusterRadius, MinSize: defaultClusterMinSize}
+	clusters       ClusterResult // latest detection
+)
+
+// changeClusterOptions replaces the clustering options
+func changeClusterOptions(o ClusterOptions) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	clusterOptions = o
+	return nil
+}
+
+// detectClusters links boids closer than the cluster radius into flocks
+// with union-find and writes each boid's label to Boid.Cluster. Labels run
+// from 0 for the largest flock; ties go to the flock holding the lower index.
+// Flocks are then matched to the previous detection by trackFlocks.
+func detectClusters() ClusterResult {
+	ensureSpatialGrid()
+
+	sets := newUnionFind(len(boids))
+	for i := range boids {
+		for _, j := range spatialGrid.QueryRadius(flock.Position(i), clusterOptions.Radius) {
+			if j > i {
+				sets.union(i, j)
+			}
+		}
+	}
+
+	// Collect groups in order of their lowest member
+	groupOf := make(map[int]int)
+	var groupSizes []int
+	for i := range boids {
+		root := sets.find(i)
+		group, ok := groupOf[root]
+		if !ok {
+			group = len(groupSizes)
+			groupOf[root] = group
+			groupSizes = append(groupSizes, 0)
+		}
+		groupSizes[group]++
+	}
+
+	// Rank the groups that are big enough to count as flocks
+	var ranked []int
+	for group, size := range groupSizes {
+		if size >= clusterOptions.MinSize {
+			ranked = append(ranked, group)
+		}
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return groupSizes[ranked[a]] > groupSizes[ranked[b]]
+	})
+
+	labelOf := make([]int, len(groupSizes))
+	for group := range labelOf {
+		labelOf[group] = noCluster
+	}
+	result := ClusterResult{Step: stepCount, Sizes: make([]int, len(ranked))}
+	for label, group := range ranked {
+		labelOf[group] = label
+		result.Sizes[label] = groupSizes[group]
+	}
+
+	for i := range boids {
+		boids[i].Cluster = labelOf[groupOf[sets.find(i)]]
+		if boids[i].Cluster == noCluster {
+			result.Unlabeled++
+		}
+	}
+
+	clusters = result
+	trackFlocks(result)
+	return result
+}
+
+// unionFind is a disjoint-set forest with path halving and union by size
+type unionFind struct {
+	parent []int
+	size   []int
+}
+
+func newUnionFind(n int) *unionFind {
+	u := &unionFind{parent: make([]int, n), size: make([]int, n)}
+	for i := range u.parent {
+		u.parent[i] = i
+		u.size[i] = 1
+	}
+	return u
+}
+
+func (u *unionFind) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
+
+func (u *unionFind) union(a, b int) {
+	a, b = u.find(a), u.find(b)
+	if a == b {
+		return
+	}
+	if u.size[a] < u.size[b] {
+		a, b = b, a
+	}
+	u.parent[b] = a
+	u.size[a] += u.size[b]
+}
+
+// clustersToMap converts a clustering summary to a JS-friendly map
+func clustersToMap(r ClusterResult) map[string]interface{} {
+	sizes := make([]interface{}, len(r.Sizes))
+	for i, size := range r.Sizes {
+		sizes[i] = size
+	}
+
+	return map[string]interface{}{
+		"step":      r.Step,
+		"count":     r.Count(),
+		"sizes":     sizes,
+		"unlabeled": r.Unlabeled,
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDetectClustersLabelsFlocks(t *testing.T) {
+	params = DefaultSimulationParams()
+	clusterOptions = ClusterOptions{Radius: 30, MinSize: 2}
+	loadBoids([]Boid{
+		// Small flock chained through boid 1
+		{ID: 0, Position: Vector2{X: 100, Y: 100}},
+		{ID: 1, Position: Vector2{X: 125, Y: 100}},
+		{ID: 2, Position: Vector2{X: 150, Y: 100}},
+		// Lone straggler
+		{ID: 3, Position: Vector2{X: 400, Y: 100}},
+		// Larger flock
+		{ID: 4, Position: Vector2{X: 600, Y: 400}},
+		{ID: 5, Position: Vector2{X: 610, Y: 400}},
+		{ID: 6, Position: Vector2{X: 600, Y: 410}},
+		{ID: 7, Position: Vector2{X: 610, Y: 410}},
+	})
+
+	result := detectClusters()
+
+	if result.Count() != 2 || result.Sizes[0] != 4 || result.Sizes[1] != 3 {
+		t.Errorf("detectClusters() sizes = %v, want [4 3]", result.Sizes)
+	}
+	if result.Unlabeled != 1 {
+		t.Errorf("detectClusters() unlabeled = %d, want 1", result.Unlabeled)
+	}
+	want := []int{1, 1, 1, noCluster, 0, 0, 0, 0}
+	for i, label := range want {
+		if boids[i].Cluster != label {
+			t.Errorf("boid %d Cluster = %d, want %d", i, boids[i].Cluster, label)
+		}
+	}
+
+	// Reset global state
+	clusterOptions = ClusterOptions{Radius: defaultClusterRadius, MinSize: defaultClusterMinSize}
+	clusters = ClusterResult{}
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestDetectClustersMatchesBruteForce(t *testing.T) {
+	params = DefaultSimulationParams()
+	clusterOptions = ClusterOptions{Radius: 25, MinSize: 1}
+	rng := rand.New(rand.NewSource(31))
+
+	for _, kind := range []SpatialIndexKind{IndexGrid, IndexFlatGrid, IndexHash, IndexQuadtree, IndexKDTree} {
+		spatialIndexKind = kind
+		initSimulationFrom(rng, 400, 800.0, 600.0)
+		detectClusters()
+
+		// Boids within the radius must share a label; the brute-force sets must
+		// induce exactly the same partition
+		sets := newUnionFind(len(boids))
+		for i := range boids {
+			for j := i + 1; j < len(boids); j++ {
+				if flock.Position(i).DistanceSquared(flock.Position(j)) < 25*25 {
+					sets.union(i, j)
+				}
+			}
+		}
+		for i := range boids {
+			for j := i + 1; j < len(boids); j++ {
+				same := sets.find(i) == sets.find(j)
+				if same != (boids[i].Cluster == boids[j].Cluster) {
+					t.Fatalf("%s: boids %d and %d same flock = %v, labels %d and %d",
+						kind, i, j, same, boids[i].Cluster, boids[j].Cluster)
+				}
+			}
+		}
+	}
+
+	// Reset global state
+	spatialIndexKind = IndexGrid
+	clusterOptions = ClusterOptions{Radius: defaultClusterRadius, MinSize: defaultClusterMinSize}
+	clusters = ClusterResult{}
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestClusterOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ClusterOptions
+		wantErr bool
+	}{
+		{"defaults", ClusterOptions{Radius: defaultClusterRadius, MinSize: defaultClusterMinSize}, false},
+		{"every step", ClusterOptions{Radius: 40, MinSize: 1, Interval: 1}, false},
+		{"zero radius", ClusterOptions{Radius: 0, MinSize: 1}, true},
+		{"zero min size", ClusterOptions{Radius: 40, MinSize: 0}, true},
+		{"negative interval", ClusterOptions{Radius: 40, MinSize: 1, Interval: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Domain is a vertical strip of the world owned by one engine instance.
+// Several instances, each in its own Web Worker or process, can run one
+// flock together by exchanging halo boids along the strip borders.
+type Domain struct {
+	MinX float64 // left edge of the owned strip, inclusive
+	MaxX float64 // right edge of the owned strip, exclusive
+	Halo float64 // width of the band shared with neighboring strips
+}
+
+// Validate checks that the domain describes a usable strip
+func (d Domain) Validate() error {
+	if math.IsNaN(d.MinX) || math.IsNaN(d.MaxX) || d.MinX >= d.MaxX {
+		return fmt.Errorf("domain must have minX < maxX, got [%v, %v)", d.MinX, d.MaxX)
+	}
+	if math.IsNaN(d.Halo) || math.IsInf(d.Halo, 0) || d.Halo < 0 {
+		return fmt.Errorf("domain halo must be a non-negative finite number, got %v", d.Halo)
+	}
+	return nil
+}
+
+// Owns reports whether a boid at position p belongs to the strip.
+// Strips touching the world edge also own everything beyond it, so boids
+// sitting exactly on the wrap edge or flying into open sky are never orphaned.
+func (d Domain) Owns(p Vector2) bool {
+	if p.X < d.MinX && d.MinX > 0 {
+		return false
+	}
+	if p.X >= d.MaxX && d.MaxX < worldWidth {
+		return false
+	}
+	return true
+}
+
+// InHalo reports whether an owned boid at p is close enough to a border
+// that a neighboring strip needs it as a ghost
+func (d Domain) InHalo(p Vector2) bool {
+	return p.X < d.MinX+d.Halo || p.X >= d.MaxX-d.Halo
+}
+
+// wantsGhost reports whether a boid owned elsewhere at p can influence
+// boids in this strip
+func (d Domain) wantsGhost(p Vector2) bool {
+	return !d.Owns(p) && p.X >= d.MinX-d.Halo && p.X < d.MaxX+d.Halo
+}
+
+// interactionRadius is the farthest distance any rule looks for neighbors,
+// and so the smallest halo that reproduces a single-domain run
+func interactionRadius() float64 {
+	return math.Max(params.SeparationRadius, math.Max(params.AlignmentRadius, params.CohesionRadius))
+}
+
+// assignDomain restricts the engine to a strip of the world.
+// Boids outside the strip are dropped; a coordinator normally starts each
+// instance empty and distributes the flock with adoptBoids.
+func assignDomain(d Domain) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	domain = &d
+	ghosts = ghosts[:0]
+	filterBoids(func(i int) bool { return d.Owns(flock.Position(i)) })
+	reindexBoids()
+	return nil
+}
+
+// releaseDomain makes the engine own the whole world again
+func releaseDomain() {
+	domain = nil
+	ghosts = ghosts[:0]
+}
+
+// domainHalo returns copies of the owned boids that neighboring strips need
+// as ghosts for the next step
+func domainHalo() []Boid {
+	if domain == nil {
+		return nil
+	}
+
+	var halo []Boid
+	for i := range boids {
+		if domain.InHalo(flock.Position(i)) {
+			halo = append(halo, boidAt(i))
+		}
+	}
+	return halo
+}
+
+// takeEmigrants removes and returns the boids that have left the strip
+func takeEmigrants() []Boid {
+	if domain == nil {
+		return nil
+	}
+
+	emigrants := filterBoids(func(i int) bool { return domain.Owns(flock.Position(i)) })
+	if len(emigrants) > 0 {
+		reindexBoids()
+	}
+	return emigrants
+}
+
+// adoptBoids takes ownership of the given boids, keeping their IDs.
+// Boids that belong to another strip are ignored, so a coordinator may
+// broadcast emigrants to every instance. Returns the number accepted. A boid
+// whose ID this instance already has, or that appears twice, rejects the
+// whole batch: instances allocate IDs independently, so two different boids
+// can share one.
+func adoptBoids(incoming []Boid) (int, error) {
+	var accepted []Boid
+	seen := make(map[int]bool)
+	for _, boid := range incoming {
+		if domain != nil && !domain.Owns(boid.Position) {
+			continue
+		}
+		if _, owned := boidIndex[boid.ID]; owned || seen[boid.ID] {
+			return 0, fmt.Errorf("boid id %d is already in use", boid.ID)
+		}
+		seen[boid.ID] = true
+		accepted = append(accepted, boid)
+	}
+
+	for _, boid := range accepted {
+		appendBoid(boid)
+		if boid.ID >= nextBoidID {
+			nextBoidID = boid.ID + 1
+		}
+	}
+	if len(accepted) > 0 {
+		reindexBoids()
+	}
+	return len(accepted), nil
+}
+
+// addGhosts adds ghosts for the next step. Ghosts are read-only neighbors:
+// they are never integrated or exported and are discarded after one step.
+// Boids out of reach of the strip are ignored. Returns the number accepted.
+func addGhosts(incoming []Boid) int {
+	if domain == nil {
+		return 0
+	}
+
+	accepted := 0
+	for _, boid := range incoming {
+		if domain.wantsGhost(boid.Position) {
+			ghosts = append(ghosts, boid)
+			accepted++
+		}
+	}
+	return accepted
+}
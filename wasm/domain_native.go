@@ -0,0 +1,197 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// engineState is the per-instance part of the global engine state.
+// In the browser every Web Worker has its own WASM instance; natively the
+// partitions share one process, so each swaps its state in before touching
+// the engine.
+type engineState struct {
+	boids        []boidAttrs
+	ghosts       []Boid
+	boidIndex    map[int]int
+	flock        BoidArrays
+	spatialGrid  SpatialIndex
+	gridStale    bool
+	cohesionTree *Quadtree
+	forceCursor  int
+	stepCount    int
+	domain       *Domain
+}
+
+// engineMu serializes access to the global engine between partitions
+var engineMu sync.Mutex
+
+// saveEngine captures the per-instance engine state
+func saveEngine() engineState {
+	return engineState{
+		boids:        boids,
+		ghosts:       ghosts,
+		boidIndex:    boidIndex,
+		flock:        flock,
+		spatialGrid:  spatialGrid,
+		gridStale:    spatialGridStale,
+		cohesionTree: cohesionTree,
+		forceCursor:  forceCursor,
+		stepCount:    stepCount,
+		domain:       domain,
+	}
+}
+
+// loadEngine restores state captured by saveEngine
+func loadEngine(s engineState) {
+	boids = s.boids
+	ghosts = s.ghosts
+	boidIndex = s.boidIndex
+	flock = s.flock
+	spatialGrid = s.spatialGrid
+	spatialGridStale = s.gridStale
+	cohesionTree = s.cohesionTree
+	forceCursor = s.forceCursor
+	stepCount = s.stepCount
+	domain = s.domain
+}
+
+// withEngine runs fn against a partition's engine state
+func (s *engineState) withEngine(fn func()) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+
+	saved := saveEngine()
+	loadEngine(*s)
+	fn()
+	*s = saveEngine()
+	loadEngine(saved)
+}
+
+// stripDomains splits the world into n equal vertical strips
+func stripDomains(n int, halo float64) []Domain {
+	domains := make([]Domain, n)
+	width := worldWidth / float64(n)
+	for i := range domains {
+		domains[i] = Domain{MinX: float64(i) * width, MaxX: float64(i+1) * width, Halo: halo}
+	}
+	domains[n-1].MaxX = worldWidth
+	return domains
+}
+
+// checkPartitionable rejects features whose state is global rather than
+// part of engineState; partitions would interleave their records in it
+func checkPartitionable() error {
+	switch {
+	case heatmap != nil:
+		return fmt.Errorf("the heatmap is not supported with partitions")
+	case trails != nil:
+		return fmt.Errorf("trails are not supported with partitions")
+	case forceDebug:
+		return fmt.Errorf("force debugging is not supported with partitions")
+	case statsHistory.enabled():
+		return fmt.Errorf("stats history is not supported with partitions")
+	case clusterOptions.Interval > 0:
+		return fmt.Errorf("automatic flock detection is not supported with partitions")
+	}
+	return nil
+}
+
+// runPartitioned steps a flock for the given number of steps split across n
+// strips, one goroutine per strip, exchanging halos and emigrants over
+// channels the way Web Workers would exchange messages. The result is
+// sorted by boid ID. Global parameters and world settings are shared.
+func runPartitioned(initial []Boid, n, steps int, halo float64) ([]Boid, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("partition count must be at least 1, got %d", n)
+	}
+	if err := checkPartitionable(); err != nil {
+		return nil, err
+	}
+
+	domains := stripDomains(n, halo)
+	states := make([]engineState, n)
+	for p := range states {
+		var err error
+		states[p].flock = newBoidArrays(precision)
+		states[p].withEngine(func() {
+			if err = assignDomain(domains[p]); err == nil {
+				_, err = adoptBoids(initial)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Separate inboxes per phase so a fast partition's next message never
+	// mixes with the current exchange
+	haloInbox := make([]chan []Boid, n)
+	migrantInbox := make([]chan []Boid, n)
+	for p := 0; p < n; p++ {
+		haloInbox[p] = make(chan []Boid, n)
+		migrantInbox[p] = make(chan []Boid, n)
+	}
+	broadcast := func(inbox []chan []Boid, from int, msg []Boid) {
+		for p := range inbox {
+			if p != from {
+				inbox[p] <- msg
+			}
+		}
+	}
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for p := 0; p < n; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			state := &states[p]
+			for step := 0; step < steps; step++ {
+				var border, emigrants []Boid
+				state.withEngine(func() { border = domainHalo() })
+				broadcast(haloInbox, p, border)
+
+				received := make([][]Boid, 0, n-1)
+				for i := 0; i < n-1; i++ {
+					received = append(received, <-haloInbox[p])
+				}
+				state.withEngine(func() {
+					for _, msg := range received {
+						addGhosts(msg)
+					}
+					stepSimulation()
+					emigrants = takeEmigrants()
+				})
+				broadcast(migrantInbox, p, emigrants)
+
+				received = received[:0]
+				for i := 0; i < n-1; i++ {
+					received = append(received, <-migrantInbox[p])
+				}
+				state.withEngine(func() {
+					for _, msg := range received {
+						if _, err := adoptBoids(msg); err != nil && errs[p] == nil {
+							errs[p] = err
+						}
+					}
+				})
+			}
+		}(p)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []Boid
+	for p := range states {
+		states[p].withEngine(func() { result = append(result, snapshotBoids()...) })
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
@@ -0,0 +1,87 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestRunPartitionedMatchesSingleDomain(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(7)), 1500, 1200.0, 800.0)
+	initial := snapshotBoids()
+
+	const steps = 30
+	for i := 0; i < steps; i++ {
+		stepSimulation()
+	}
+	single := snapshotBoids()
+	sort.Slice(single, func(i, j int) bool { return single[i].ID < single[j].ID })
+
+	for _, parts := range []int{1, 2, 3, 5} {
+		loadBoids(nil)
+		spatialGrid = nil
+		stepCount = 0
+
+		result, err := runPartitioned(initial, parts, steps, interactionRadius())
+		if err != nil {
+			t.Fatalf("runPartitioned(%d) error: %v", parts, err)
+		}
+		if len(result) != len(single) {
+			t.Fatalf("runPartitioned(%d) returned %d boids, want %d", parts, len(result), len(single))
+		}
+
+		// Neighbor sums run in a different order per partition, so allow rounding drift
+		for i := range single {
+			if result[i].ID != single[i].ID {
+				t.Fatalf("runPartitioned(%d) boid %d has ID %d, want %d", parts, i, result[i].ID, single[i].ID)
+			}
+			dp := result[i].Position.Sub(single[i].Position).Magnitude()
+			dv := result[i].Velocity.Sub(single[i].Velocity).Magnitude()
+			if dp > 1e-6 || dv > 1e-6 {
+				t.Fatalf("runPartitioned(%d) boid %d at %+v, want %+v", parts, result[i].ID, result[i], single[i])
+			}
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	stepCount = 0
+	params = SimulationParams{}
+}
+
+func TestRunPartitionedRejectsZeroPartitions(t *testing.T) {
+	if _, err := runPartitioned(nil, 0, 1, 50); err == nil {
+		t.Errorf("runPartitioned(0) error = nil, want error")
+	}
+}
+
+func TestRunPartitionedRejectsGlobalRecorders(t *testing.T) {
+	tests := []struct {
+		name   string
+		enable func()
+	}{
+		{"trails", func() { configureTrails(4) }},
+		{"force debug", func() { changeForceDebug(true) }},
+		{"stats history", func() { changeStatsHistoryLength(10) }},
+		{"flock detection", func() { clusterOptions.Interval = 5 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.enable()
+			if _, err := runPartitioned(nil, 2, 1, 50); err == nil {
+				t.Errorf("runPartitioned() with %s error = nil, want error", tt.name)
+			}
+
+			// Reset global state
+			configureTrails(0)
+			changeForceDebug(false)
+			changeStatsHistoryLength(0)
+			clusterOptions.Interval = 0
+		})
+	}
+}
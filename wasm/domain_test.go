@@ -0,0 +1,121 @@
+package main
+
+import "testing"
+
+func TestDomainValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  Domain
+		wantErr bool
+	}{
+		{"valid", Domain{MinX: 0, MaxX: 400, Halo: 50}, false},
+		{"no halo", Domain{MinX: 100, MaxX: 200}, false},
+		{"empty", Domain{MinX: 200, MaxX: 200, Halo: 50}, true},
+		{"inverted", Domain{MinX: 300, MaxX: 200, Halo: 50}, true},
+		{"negative halo", Domain{MinX: 0, MaxX: 400, Halo: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.domain.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDomainOwnership(t *testing.T) {
+	worldWidth = 800.0
+	left := Domain{MinX: 0, MaxX: 400, Halo: 50}
+	right := Domain{MinX: 400, MaxX: 800, Halo: 50}
+
+	tests := []struct {
+		x         float64
+		wantLeft  bool
+		wantRight bool
+	}{
+		{-5, true, false},
+		{0, true, false},
+		{399.9, true, false},
+		{400, false, true},
+		{800, false, true}, // WrapAround can leave boids exactly on the edge
+	}
+
+	for _, tt := range tests {
+		p := Vector2{X: tt.x, Y: 100}
+		if got := left.Owns(p); got != tt.wantLeft {
+			t.Errorf("left.Owns(%v) = %v, want %v", tt.x, got, tt.wantLeft)
+		}
+		if got := right.Owns(p); got != tt.wantRight {
+			t.Errorf("right.Owns(%v) = %v, want %v", tt.x, got, tt.wantRight)
+		}
+	}
+}
+
+func TestDomainExchange(t *testing.T) {
+	params = DefaultSimulationParams()
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{
+		{ID: 1, Position: Vector2{X: 100, Y: 100}},
+		{ID: 2, Position: Vector2{X: 380, Y: 100}},
+		{ID: 3, Position: Vector2{X: 600, Y: 100}},
+	})
+
+	if err := assignDomain(Domain{MinX: 0, MaxX: 400, Halo: 50}); err != nil {
+		t.Fatalf("assignDomain() error: %v", err)
+	}
+	if len(boids) != 2 {
+		t.Fatalf("assignDomain() kept %d boids, want 2", len(boids))
+	}
+
+	halo := domainHalo()
+	if len(halo) != 1 || halo[0].ID != 2 {
+		t.Errorf("domainHalo() = %+v, want boid 2", halo)
+	}
+
+	// Only boids within the halo of the strip become ghosts
+	got := addGhosts([]Boid{
+		{ID: 10, Position: Vector2{X: 420, Y: 100}},
+		{ID: 11, Position: Vector2{X: 600, Y: 100}},
+		{ID: 12, Position: Vector2{X: 200, Y: 100}}, // owned by this strip, not a ghost
+	})
+	if got != 1 || len(ghosts) != 1 || ghosts[0].ID != 10 {
+		t.Errorf("addGhosts() = %d with ghosts %+v, want only boid 10", got, ghosts)
+	}
+
+	if got, err := adoptBoids([]Boid{{ID: 20, Position: Vector2{X: 50, Y: 50}}, {ID: 21, Position: Vector2{X: 500, Y: 50}}}); got != 1 || err != nil {
+		t.Errorf("adoptBoids() = (%d, %v), want (1, nil)", got, err)
+	}
+	if nextBoidID <= 20 {
+		t.Errorf("nextBoidID = %d, want > 20 after importing boid 20", nextBoidID)
+	}
+
+	// An ID already in use, or repeated in the batch, rejects the whole batch
+	for _, batch := range [][]Boid{
+		{{ID: 30, Position: Vector2{X: 60, Y: 60}}, {ID: 20, Position: Vector2{X: 70, Y: 70}}},
+		{{ID: 31, Position: Vector2{X: 60, Y: 60}}, {ID: 31, Position: Vector2{X: 70, Y: 70}}},
+	} {
+		if got, err := adoptBoids(batch); got != 0 || err == nil {
+			t.Errorf("adoptBoids(%+v) = (%d, %v), want (0, error)", batch, got, err)
+		}
+	}
+	if len(boids) != 3 {
+		t.Errorf("boid count after rejected adoption = %d, want 3", len(boids))
+	}
+
+	flock.SetPosition(0, Vector2{X: 401, Y: flock.Position(0).Y})
+	emigrants := takeEmigrants()
+	if len(emigrants) != 1 || emigrants[0].ID != 1 {
+		t.Errorf("takeEmigrants() = %+v, want boid 1", emigrants)
+	}
+	if _, ok := lookupBoidIndex(1); ok {
+		t.Errorf("lookupBoidIndex(1) found an emigrated boid")
+	}
+
+	// Reset global state
+	releaseDomain()
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
@@ -0,0 +1,151 @@
+package main
+
+import "math"
+
+// FlatGrid is a uniform grid stored as one flat array. Boids are bucketed by
+// a counting sort into entries, with cellStart giving each cell's offset, so
+// a row of neighboring cells is one contiguous run of memory instead of a
+// separate slice per cell. The buckets are rebuilt lazily on the first query
+// after inserts.
+type FlatGrid struct {
+	cellSize float64
+	cols     int
+	rows     int
+
+	cellStart []int // offset of each cell in entries; cellStart[len] == len(entries)
+	entries   []int // boid indices sorted by cell
+	itemCell  []int // cell of each pending insert
+	itemBoid  []int // boid index of each pending insert
+	points    []Vector2
+	dirty     bool
+}
+
+// NewFlatGrid creates a new flat grid
+func NewFlatGrid(width, height, cellSize float64) *FlatGrid {
+	cols := int(math.Ceil(width / cellSize))
+	rows := int(math.Ceil(height / cellSize))
+
+	return &FlatGrid{
+		cellSize:  cellSize,
+		cols:      cols,
+		rows:      rows,
+		cellStart: make([]int, rows*cols+1),
+	}
+}
+
+// Clear removes every boid from the grid
+func (fg *FlatGrid) Clear() {
+	fg.itemCell = fg.itemCell[:0]
+	fg.itemBoid = fg.itemBoid[:0]
+	fg.dirty = true
+}
+
+// Insert adds a boid to the grid; boids outside the grid are ignored
+func (fg *FlatGrid) Insert(boidIndex int, position Vector2) {
+	// Boids on the far edge or outside the grid go to the nearest border cell
+	row, col := fg.getCellCoords(position)
+	row, col = min(max(row, 0), fg.rows-1), min(max(col, 0), fg.cols-1)
+
+	fg.itemCell = append(fg.itemCell, row*fg.cols+col)
+	fg.itemBoid = append(fg.itemBoid, boidIndex)
+	fg.points = setPoint(fg.points, boidIndex, position)
+	fg.dirty = true
+}
+
+// Build buckets the pending inserts by cell with a counting sort
+func (fg *FlatGrid) Build() {
+	if !fg.dirty {
+		return
+	}
+	fg.dirty = false
+
+	// Count boids per cell, then turn counts into start offsets
+	for i := range fg.cellStart {
+		fg.cellStart[i] = 0
+	}
+	for _, cell := range fg.itemCell {
+		fg.cellStart[cell+1]++
+	}
+	for i := 1; i < len(fg.cellStart); i++ {
+		fg.cellStart[i] += fg.cellStart[i-1]
+	}
+
+	if cap(fg.entries) < len(fg.itemBoid) {
+		fg.entries = make([]int, len(fg.itemBoid))
+	}
+	fg.entries = fg.entries[:len(fg.itemBoid)]
+
+	// Scatter in insertion order; the cursor for each cell is its start offset
+	// shifted one cell down, so after scattering cellStart is intact again
+	cursor := fg.cellStart
+	for i, cell := range fg.itemCell {
+		fg.entries[cursor[cell]] = fg.itemBoid[i]
+		cursor[cell]++
+	}
+	copy(fg.cellStart[1:], fg.cellStart[:len(fg.cellStart)-1])
+	fg.cellStart[0] = 0
+}
+
+// GetNeighbors returns all boid indices in cells within the given radius
+func (fg *FlatGrid) GetNeighbors(position Vector2, radius float64) []int {
+	fg.Build()
+	neighbors := make([]int, 0, 20) // pre-allocate
+
+	cellRadius := int(math.Ceil(radius / fg.cellSize))
+	centerRow, centerCol := fg.getCellCoords(position)
+	minCol, maxCol := max(centerCol-cellRadius, 0), min(centerCol+cellRadius, fg.cols-1)
+	if minCol > maxCol {
+		return neighbors
+	}
+
+	// Cells of a row are adjacent, so each row is a single contiguous run
+	for row := max(centerRow-cellRadius, 0); row <= min(centerRow+cellRadius, fg.rows-1); row++ {
+		start := fg.cellStart[row*fg.cols+minCol]
+		end := fg.cellStart[row*fg.cols+maxCol+1]
+		neighbors = append(neighbors, fg.entries[start:end]...)
+	}
+
+	return neighbors
+}
+
+// QueryRadius returns the boid indices strictly within radius
+func (fg *FlatGrid) QueryRadius(position Vector2, radius float64) []int {
+	return filterWithinRadius(fg.GetNeighbors(position, radius), fg.points, position, radius)
+}
+
+// KNearest returns up to k boid indices ordered by increasing distance
+func (fg *FlatGrid) KNearest(position Vector2, k int) []int {
+	return kNearestByExpansion(func(radius float64) []int {
+		return fg.QueryRadius(position, radius)
+	}, fg.points, position, k, len(fg.itemBoid), fg.cellSize)
+}
+
+// QueryRect returns all boid indices in cells overlapping the rectangle
+func (fg *FlatGrid) QueryRect(rect Rect) []int {
+	fg.Build()
+	result := make([]int, 0, 64)
+
+	minRow, minCol := fg.getCellCoords(Vector2{X: rect.X, Y: rect.Y})
+	maxRow, maxCol := fg.getCellCoords(Vector2{X: rect.X + rect.Width, Y: rect.Y + rect.Height})
+	minRow, minCol = max(minRow, 0), max(minCol, 0)
+	maxRow, maxCol = min(maxRow, fg.rows-1), min(maxCol, fg.cols-1)
+	if minCol > maxCol {
+		return result
+	}
+
+	for row := minRow; row <= maxRow; row++ {
+		start := fg.cellStart[row*fg.cols+minCol]
+		end := fg.cellStart[row*fg.cols+maxCol+1]
+		result = append(result, fg.entries[start:end]...)
+	}
+
+	return result
+}
+
+// getCellCoords converts world position to grid coordinates, truncating like
+// SpatialGrid so boids just past the top or left edge stay in the first cells
+func (fg *FlatGrid) getCellCoords(position Vector2) (int, int) {
+	col := int(position.X / fg.cellSize)
+	row := int(position.Y / fg.cellSize)
+	return row, col
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestFlatGridMatchesSpatialGrid(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	grid := NewSpatialGrid(800.0, 600.0, 75.0)
+	flat := NewFlatGrid(800.0, 600.0, 75.0)
+
+	positions := uniformPositions(rng, 300, 800.0, 600.0)
+	for i, p := range positions {
+		grid.Insert(i, p)
+		flat.Insert(i, p)
+	}
+
+	// Candidate sets are identical, cell for cell
+	for _, center := range positions[:30] {
+		want := grid.GetNeighbors(center, 60.0)
+		got := flat.GetNeighbors(center, 60.0)
+		sort.Ints(want)
+		sort.Ints(got)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("GetNeighbors(%v) = %v, want %v", center, got, want)
+		}
+	}
+
+	// Rebuilding after Clear drops the old boids
+	flat.Clear()
+	flat.Insert(0, Vector2{X: 10.0, Y: 10.0})
+	if got := flat.GetNeighbors(Vector2{X: 400.0, Y: 300.0}, 1000.0); len(got) != 1 {
+		t.Errorf("GetNeighbors() after Clear = %v, want [0]", got)
+	}
+}
+
+// setupBenchmarkFlock spawns count boids at a constant density, sizing the world to fit
+func setupBenchmarkFlock(count int, kind SpatialIndexKind, reorderInterval int) {
+	// 1000 boids on an 800x600 canvas is the density the defaults were tuned for
+	width := math.Sqrt(float64(count) * 480.0 * 4.0 / 3.0)
+	height := width * 3.0 / 4.0
+
+	params = DefaultSimulationParams()
+	spatialIndexKind = kind
+	mortonReorderInterval = reorderInterval
+	stepCount = 0
+	initSimulation(count, width, height)
+}
+
+func BenchmarkStepSimulation(b *testing.B) {
+	variants := []struct {
+		name            string
+		kind            SpatialIndexKind
+		reorderInterval int
+	}{
+		{"grid", IndexGrid, 0},
+		{"grid+morton", IndexGrid, defaultMortonReorderInterval},
+		{"flatgrid", IndexFlatGrid, 0},
+		{"flatgrid+morton", IndexFlatGrid, defaultMortonReorderInterval},
+	}
+
+	for _, count := range []int{10000, 20000, 50000} {
+		for _, variant := range variants {
+			b.Run(fmt.Sprintf("%d/%s", count, variant.name), func(b *testing.B) {
+				setupBenchmarkFlock(count, variant.kind, variant.reorderInterval)
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					stepSimulation()
+				}
+				b.ReportMetric(float64(count)*float64(b.N)/b.Elapsed().Seconds(), "boids/s")
+			})
+		}
+	}
+
+	// Reset global state
+	spatialIndexKind = IndexGrid
+	mortonReorderInterval = defaultMortonReorderInterval
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
@@ -0,0 +1,209 @@
+package main
+
+import "sort"
+
+// maxFlockEvents bounds the event queue; the oldest events are dropped first
+const maxFlockEvents = 1024
+
+// FlockEventType is what happened to a flock between two detections
+type FlockEventType string
+
+const (
+	FlockFormed    FlockEventType = "formed"    // a flock appeared from unclustered boids
+	FlockSplit     FlockEventType = "split"     // a flock broke into several
+	FlockMerged    FlockEventType = "merged"    // several flocks joined into one
+	FlockDissolved FlockEventType = "dissolved" // a flock lost all of its members
+)
+
+// FlockEvent describes a change in flock identity. For splits, Flock is the
+// original flock and Parts the flocks it broke into; for merges, Flock is the
+// resulting flock and Parts the flocks that joined it.
+type FlockEvent struct {
+	Type      FlockEventType
+	Step      int
+	Flock     int
+	Size      int // size after the event; for splits and dissolves, before it
+	Parts     []int
+	PartSizes []int // boids each part shares with Flock
+}
+
+// Flock tracking state
+var (
+	flockOf     map[int]int // boid ID -> persistent flock ID at the last detection
+	flockSizes  map[int]int // flock ID -> size at the last detection
+	nextFlockID int
+	flockEvents []FlockEvent // events not yet delivered to JavaScript
+)
+
+// trackFlocks gives the clusters from the latest detection persistent flock
+// IDs, stored in Boid.Flock, and queues the events that explain how they
+// relate to the flocks of the previous detection. A cluster keeps the ID of
+// the previous flock it shares the most boids with; the remaining pieces of
+// a split get new IDs. Only overlaps of at least the cluster minimum size
+// link flocks, so a few boids changing flock is not a split or a merge.
+func trackFlocks(result ClusterResult) {
+	// overlap[label][previous flock] counts boids that moved between them
+	overlap := make([]map[int]int, result.Count())
+	for label := range overlap {
+		overlap[label] = make(map[int]int)
+	}
+	for i := range boids {
+		label := boids[i].Cluster
+		if label == noCluster {
+			continue
+		}
+		if prev, ok := flockOf[boids[i].ID]; ok {
+			overlap[label][prev]++
+		}
+	}
+	for _, sources := range overlap {
+		for prev, shared := range sources {
+			if shared < clusterOptions.MinSize {
+				delete(sources, prev)
+			}
+		}
+	}
+
+	// Assign inherited IDs greedily, largest overlap first
+	type match struct{ label, prev, shared int }
+	var matches []match
+	for label, sources := range overlap {
+		for prev, shared := range sources {
+			matches = append(matches, match{label, prev, shared})
+		}
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].shared != matches[b].shared {
+			return matches[a].shared > matches[b].shared
+		}
+		if matches[a].prev != matches[b].prev {
+			return matches[a].prev < matches[b].prev
+		}
+		return matches[a].label < matches[b].label
+	})
+
+	idOf := make([]int, result.Count())
+	for label := range idOf {
+		idOf[label] = -1
+	}
+	claimed := make(map[int]bool)
+	for _, m := range matches {
+		if idOf[m.label] == -1 && !claimed[m.prev] {
+			idOf[m.label] = m.prev
+			claimed[m.prev] = true
+		}
+	}
+	for label := range idOf {
+		if idOf[label] == -1 {
+			idOf[label] = nextFlockID
+			nextFlockID++
+		}
+	}
+
+	// Work out which current flocks each previous flock went to
+	pieces := make(map[int][]int)
+	for label, sources := range overlap {
+		for prev := range sources {
+			pieces[prev] = append(pieces[prev], label)
+		}
+	}
+
+	step := result.Step
+	var events []FlockEvent
+	for _, prev := range sortedKeys(flockSizes) {
+		labels := pieces[prev]
+		switch {
+		case len(labels) == 0:
+			events = append(events, FlockEvent{Type: FlockDissolved, Step: step, Flock: prev, Size: flockSizes[prev]})
+		case len(labels) > 1:
+			event := FlockEvent{Type: FlockSplit, Step: step, Flock: prev, Size: flockSizes[prev]}
+			sort.Ints(labels)
+			for _, label := range labels {
+				event.Parts = append(event.Parts, idOf[label])
+				event.PartSizes = append(event.PartSizes, overlap[label][prev])
+			}
+			events = append(events, event)
+		}
+	}
+	for label, sources := range overlap {
+		switch {
+		case len(sources) == 0:
+			events = append(events, FlockEvent{Type: FlockFormed, Step: step, Flock: idOf[label], Size: result.Sizes[label]})
+		case len(sources) > 1:
+			event := FlockEvent{Type: FlockMerged, Step: step, Flock: idOf[label], Size: result.Sizes[label]}
+			for _, prev := range sortedKeys(sources) {
+				event.Parts = append(event.Parts, prev)
+				event.PartSizes = append(event.PartSizes, sources[prev])
+			}
+			events = append(events, event)
+		}
+	}
+	queueFlockEvents(events)
+
+	// Remember this detection for the next one
+	flockOf = make(map[int]int, len(boids)-result.Unlabeled)
+	flockSizes = make(map[int]int, result.Count())
+	for i := range boids {
+		if label := boids[i].Cluster; label != noCluster {
+			boids[i].Flock = idOf[label]
+			flockOf[boids[i].ID] = idOf[label]
+		} else {
+			boids[i].Flock = noCluster
+		}
+	}
+	for label, size := range result.Sizes {
+		flockSizes[idOf[label]] = size
+	}
+}
+
+// queueFlockEvents appends events, dropping the oldest past maxFlockEvents
+func queueFlockEvents(events []FlockEvent) {
+	flockEvents = append(flockEvents, events...)
+	if excess := len(flockEvents) - maxFlockEvents; excess > 0 {
+		flockEvents = append(flockEvents[:0], flockEvents[excess:]...)
+	}
+}
+
+// drainFlockEvents returns the queued events and empties the queue
+func drainFlockEvents() []FlockEvent {
+	events := flockEvents
+	flockEvents = nil
+	return events
+}
+
+// resetFlockTracking forgets every tracked flock and pending event
+func resetFlockTracking() {
+	flockOf = nil
+	flockSizes = nil
+	nextFlockID = 0
+	flockEvents = nil
+}
+
+// sortedKeys returns the keys of m in increasing order
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// flockEventToMap converts an event to a JS-friendly map
+func flockEventToMap(e FlockEvent) map[string]interface{} {
+	parts := make([]interface{}, len(e.Parts))
+	partSizes := make([]interface{}, len(e.PartSizes))
+	for i := range e.Parts {
+		parts[i] = e.Parts[i]
+		partSizes[i] = e.PartSizes[i]
+	}
+
+	return map[string]interface{}{
+		"type":      string(e.Type),
+		"step":      e.Step,
+		"flock":     e.Flock,
+		"size":      e.Size,
+		"parts":     parts,
+		"partSizes": partSizes,
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// placeGroups puts boids with the given IDs in tight groups far apart,
+// one group per entry, and detects clusters
+func placeGroups(groups [][]int) ClusterResult {
+	loadBoids(nil)
+	for g, ids := range groups {
+		for k, id := range ids {
+			appendBoid(Boid{
+				ID:       id,
+				Position: Vector2{X: 50 + float64(g)*200 + float64(k%3)*5, Y: 50 + float64(k/3)*5},
+				Cluster:  noCluster,
+				Flock:    noCluster,
+			})
+		}
+	}
+	reindexBoids()
+	return detectClusters()
+}
+
+func TestTrackFlocksEvents(t *testing.T) {
+	params = DefaultSimulationParams()
+	worldWidth, worldHeight = 2000.0, 600.0
+	clusterOptions = ClusterOptions{Radius: 20, MinSize: 2}
+	resetFlockTracking()
+
+	tests := []struct {
+		name   string
+		groups [][]int
+		want   []FlockEvent
+	}{
+		{
+			name:   "two flocks form",
+			groups: [][]int{{0, 1, 2, 3, 8}, {4, 5, 6}},
+			want: []FlockEvent{
+				{Type: FlockFormed, Flock: 0, Size: 5},
+				{Type: FlockFormed, Flock: 1, Size: 3},
+			},
+		},
+		{
+			name:   "steady flocks emit nothing",
+			groups: [][]int{{4, 5, 6}, {0, 1, 2, 3, 8}},
+		},
+		{
+			name:   "first flock splits, larger piece keeps its ID",
+			groups: [][]int{{0, 1, 2}, {3, 7, 8}, {4, 5, 6}},
+			want: []FlockEvent{
+				{Type: FlockSplit, Flock: 0, Size: 5, Parts: []int{0, 2}, PartSizes: []int{3, 2}},
+			},
+		},
+		{
+			name:   "part of the other flock merges in",
+			groups: [][]int{{0, 1, 2, 4, 5}, {3, 7, 8}, {6}},
+			want: []FlockEvent{
+				{Type: FlockMerged, Flock: 0, Size: 5, Parts: []int{0, 1}, PartSizes: []int{3, 2}},
+			},
+		},
+		{
+			name:   "small flock dissolves",
+			groups: [][]int{{0, 1, 2, 4, 5, 6}, {3}, {7}, {8}},
+			want: []FlockEvent{
+				{Type: FlockDissolved, Flock: 2, Size: 3},
+			},
+		},
+	}
+
+	for step, tt := range tests {
+		stepCount = step
+		placeGroups(tt.groups)
+		got := drainFlockEvents()
+		for i := range tt.want {
+			tt.want[i].Step = step
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s: events = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Boids carry their persistent flock ID
+	for i := range boids {
+		want := 0
+		if boids[i].ID == 3 || boids[i].ID == 7 || boids[i].ID == 8 {
+			want = noCluster
+		}
+		if boids[i].Flock != want {
+			t.Errorf("boid %d Flock = %d, want %d", boids[i].ID, boids[i].Flock, want)
+		}
+	}
+
+	// Reset global state
+	resetFlockTracking()
+	clusterOptions = ClusterOptions{Radius: defaultClusterRadius, MinSize: defaultClusterMinSize}
+	clusters = ClusterResult{}
+	worldWidth, worldHeight = 800.0, 600.0
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestTrackFlocksIgnoresSingleBoidTransfer(t *testing.T) {
+	params = DefaultSimulationParams()
+	worldWidth, worldHeight = 2000.0, 600.0
+	clusterOptions = ClusterOptions{Radius: 20, MinSize: 2}
+	resetFlockTracking()
+
+	placeGroups([][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {10, 11, 12, 13, 14, 15, 16, 17, 18, 19}})
+	drainFlockEvents()
+
+	// Boid 9 leaves the first flock for the second
+	stepCount = 1
+	placeGroups([][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8}, {9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}})
+	if events := drainFlockEvents(); len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+	index, _ := lookupBoidIndex(9)
+	if boids[index].Flock != 1 {
+		t.Errorf("moved boid Flock = %d, want 1", boids[index].Flock)
+	}
+
+	// Reset global state
+	resetFlockTracking()
+	clusterOptions = ClusterOptions{Radius: defaultClusterRadius, MinSize: defaultClusterMinSize}
+	clusters = ClusterResult{}
+	worldWidth, worldHeight = 800.0, 600.0
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestFlockEventQueueIsBounded(t *testing.T) {
+	resetFlockTracking()
+	for i := 0; i < maxFlockEvents+10; i++ {
+		queueFlockEvents([]FlockEvent{{Type: FlockFormed, Flock: i}})
+	}
+
+	events := drainFlockEvents()
+	if len(events) != maxFlockEvents || events[0].Flock != 10 {
+		t.Errorf("queue kept %d events starting at flock %d, want %d starting at 10",
+			len(events), events[0].Flock, maxFlockEvents)
+	}
+	if len(drainFlockEvents()) != 0 {
+		t.Errorf("drainFlockEvents() did not empty the queue")
+	}
+}
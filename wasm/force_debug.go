@@ -0,0 +1,121 @@
+package main
+
+import "math"
+
+// ForceBreakdown is how one boid's steering force was put together in the
+// step that last recomputed it
+type ForceBreakdown struct {
+	ID         int
+	Step       int
+	Separation Vector2
+	Alignment  Vector2
+	Cohesion   Vector2
+	Mouse      Vector2
+	Total      Vector2        // the steering force actually applied
+	Neighbors  [ruleCount]int // neighbors each rule accepted; cohesion reads 0 under Barnes–Hut
+}
+
+// forceFields names the columns of the flat breakdown export, in order
+var forceFields = []string{
+	"separationX", "separationY", "alignmentX", "alignmentY",
+	"cohesionX", "cohesionY", "mouseX", "mouseY", "totalX", "totalY",
+	"separationNeighbors", "alignmentNeighbors", "cohesionNeighbors",
+}
+
+// Force debugging state
+var (
+	forceDebug      bool             // record a ForceBreakdown for every recomputed boid
+	forceBreakdowns []ForceBreakdown // indexed like boids at the time of the step
+)
+
+// changeForceDebug turns breakdown recording on or off, dropping old records
+func changeForceDebug(on bool) {
+	forceDebug = on
+	forceBreakdowns = nil
+}
+
+// prepareForceBreakdowns makes room for n records before workers write them
+func prepareForceBreakdowns(n int) {
+	// IDs start at 0, so empty slots need an ID no boid has
+	for len(forceBreakdowns) < n {
+		forceBreakdowns = append(forceBreakdowns, ForceBreakdown{ID: -1})
+	}
+}
+
+// forceBreakdown computes the same terms as flockingForce, in the same
+// order, but keeps them apart. stats must not be nil.
+func (b *Boid) forceBreakdown(boidIndex int, stats *neighborStats) ForceBreakdown {
+	before := stats.accepted
+	fb := ForceBreakdown{
+		ID:         b.ID,
+		Step:       stepCount,
+		Separation: b.separate(boidIndex, stats),
+		Alignment:  b.align(boidIndex, stats),
+		Cohesion:   b.cohesion(boidIndex, stats),
+		Mouse:      b.avoidMouse(),
+	}
+	fb.Total = fb.Separation.Add(fb.Alignment).Add(fb.Cohesion).Add(fb.Mouse)
+	for rule := range fb.Neighbors {
+		fb.Neighbors[rule] = int(stats.accepted[rule] - before[rule])
+	}
+	return fb
+}
+
+// lookupForceBreakdown returns the record of the boid now at index. Boids
+// reordered or added since their forces were last recomputed have none.
+func lookupForceBreakdown(index int) (ForceBreakdown, bool) {
+	if index < 0 || index >= len(boids) || index >= len(forceBreakdowns) {
+		return ForceBreakdown{}, false
+	}
+	fb := forceBreakdowns[index]
+	return fb, fb.ID == boids[index].ID
+}
+
+// flattenForceBreakdowns lays out one row per boid in forceFields order,
+// NaN for boids without a record
+func flattenForceBreakdowns() []float32 {
+	stride := len(forceFields)
+	flat := make([]float32, len(boids)*stride)
+	for i := range boids {
+		row := flat[i*stride : (i+1)*stride]
+		fb, ok := lookupForceBreakdown(i)
+		if !ok {
+			for j := range row {
+				row[j] = float32(math.NaN())
+			}
+			continue
+		}
+
+		vectors := []Vector2{fb.Separation, fb.Alignment, fb.Cohesion, fb.Mouse, fb.Total}
+		for j, v := range vectors {
+			row[j*2] = float32(v.X)
+			row[j*2+1] = float32(v.Y)
+		}
+		for rule, count := range fb.Neighbors {
+			row[len(vectors)*2+rule] = float32(count)
+		}
+	}
+	return flat
+}
+
+// forceBreakdownToMap converts a breakdown to a JS-friendly map
+func forceBreakdownToMap(fb ForceBreakdown) map[string]interface{} {
+	vector := func(v Vector2) map[string]interface{} {
+		return map[string]interface{}{"x": v.X, "y": v.Y}
+	}
+
+	return map[string]interface{}{
+		"id":         fb.ID,
+		"step":       fb.Step,
+		"separation": vector(fb.Separation),
+		"alignment":  vector(fb.Alignment),
+		"cohesion":   vector(fb.Cohesion),
+		"mouse":      vector(fb.Mouse),
+		"total":      vector(fb.Total),
+		"neighbors": map[string]interface{}{
+			"separation": fb.Neighbors[ruleSeparation],
+			"alignment":  fb.Neighbors[ruleAlignment],
+			"cohesion":   fb.Neighbors[ruleCohesion],
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestForceDebugMatchesNormalStep(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(7)), 500, 800.0, 600.0)
+	initial := snapshotBoids()
+
+	plain := runFlock(initial, 2, 5)
+	changeForceDebug(true)
+	debugged := runFlock(initial, 2, 5)
+	for i := range plain {
+		if debugged[i] != plain[i] {
+			t.Fatalf("boid %d = %+v with force debugging, want %+v", i, debugged[i], plain[i])
+		}
+	}
+
+	for i := range boids {
+		fb, ok := lookupForceBreakdown(i)
+		if !ok {
+			t.Fatalf("no breakdown for boid %d", i)
+		}
+		if fb.Total != boids[i].Steering || fb.Step != stepCount-1 {
+			t.Fatalf("breakdown %d = %+v, want total %v at step %d", i, fb, boids[i].Steering, stepCount-1)
+		}
+	}
+
+	// Reset global state
+	changeForceDebug(false)
+	forceWorkers = defaultForceWorkers
+	loadBoids(nil)
+	stepCount = 0
+}
+
+func TestForceBreakdownCountsNeighbors(t *testing.T) {
+	params = DefaultSimulationParams()
+	mousePos = Vector2{X: -1000, Y: -1000}
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{
+		NewBoid(100, 100),
+		NewBoid(110, 100), // within every radius
+		NewBoid(140, 100), // beyond separation only
+	})
+	flock.SetVelocity(1, Vector2{X: 0, Y: 1})
+	reindexBoids()
+	rebuildSpatialGrid()
+
+	b := boidAt(0)
+	fb := b.forceBreakdown(0, &neighborStats{})
+	want := [ruleCount]int{1, 2, 2}
+	if fb.Neighbors != want {
+		t.Errorf("neighbors = %v, want %v", fb.Neighbors, want)
+	}
+	if fb.Separation.X >= 0 || fb.Cohesion.X <= 0 {
+		t.Errorf("separation %v should push left and cohesion %v pull right", fb.Separation, fb.Cohesion)
+	}
+	if fb.Mouse != (Vector2{}) {
+		t.Errorf("mouse = %v, want zero with the mouse far away", fb.Mouse)
+	}
+
+	// A boid added after the step has no record yet
+	changeForceDebug(true)
+	prepareForceBreakdowns(len(boids))
+	forceBreakdowns[0] = fb
+	appendBoid(NewBoid(500, 500))
+	flat := flattenForceBreakdowns()
+	stride := len(forceFields)
+	if flat[stride-3] != 1 || !math.IsNaN(float64(flat[stride])) || !math.IsNaN(float64(flat[3*stride])) {
+		t.Errorf("flattened rows = %v", flat)
+	}
+
+	// Reset global state
+	changeForceDebug(false)
+	loadBoids(nil)
+}
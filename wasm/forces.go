@@ -0,0 +1,80 @@
+package main
+
+import "time"
+
+// parallelChunkSize is how many boids a worker claims at a time
+const parallelChunkSize = 256
+
+// computeForces recomputes the steering force of the first n boids, starting
+// at forceCursor and wrapping around, until the budget runs out. At least one
+// batch is always computed so a tight budget still makes progress.
+// Forces only read the snapshot taken by rebuildSpatialGrid, so boids can be
+// processed in any order, or concurrently, with identical results.
+// Returns the number of boids recomputed.
+func computeForces(n int, budget StepBudget, start time.Time) int {
+	if n == 0 {
+		forceCursor = 0
+		return 0
+	}
+
+	// The flock may have shrunk since the last step
+	cursor := forceCursor % n
+	batch := parallelChunkSize * forceWorkers
+	computed := 0
+	for computed < n {
+		if budget.Boids > 0 && computed >= budget.Boids {
+			break
+		}
+		if budget.Time > 0 && computed > 0 && time.Since(start) >= budget.Time {
+			break
+		}
+
+		size := min(batch, n-computed)
+		if budget.Boids > 0 {
+			size = min(size, budget.Boids-computed)
+		}
+		begin := (cursor + computed) % n
+		if forceDebug {
+			// Keep every term apart; traces then show forces without per-rule passes
+			prepareForceBreakdowns(n)
+			parallelFor(size, forceWorkers, func(first, last int) {
+				stats := newNeighborStats()
+				for j := first; j < last; j++ {
+					i := (begin + j) % n
+					b := boidAt(i)
+					fb := b.forceBreakdown(i, stats)
+					forceBreakdowns[i] = fb
+					boids[i].Steering = fb.Total
+				}
+				stats.merge()
+			})
+		} else if tracer != nil {
+			computeForcePasses(begin, size, n)
+		} else {
+			parallelFor(size, forceWorkers, func(first, last int) {
+				stats := newNeighborStats()
+				for j := first; j < last; j++ {
+					i := (begin + j) % n
+					b := boidAt(i)
+					boids[i].Steering = b.flockingForce(i, stats)
+				}
+				stats.merge()
+			})
+		}
+		computed += size
+	}
+
+	forceCursor = (cursor + computed) % n
+	return computed
+}
+
+// flockingForce sums every steering rule for the boid at boidIndex
+func (b *Boid) flockingForce(boidIndex int, stats *neighborStats) Vector2 {
+	// Calculate flocking forces using spatial grid
+	separation := b.separate(boidIndex, stats)
+	alignment := b.align(boidIndex, stats)
+	cohesionForce := b.cohesion(boidIndex, stats)
+	mouseAvoidance := b.avoidMouse()
+
+	return separation.Add(alignment).Add(cohesionForce).Add(mouseAvoidance)
+}
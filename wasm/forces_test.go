@@ -0,0 +1,136 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runFlock steps a copy of initial with the given worker count and returns the result
+func runFlock(initial []Boid, workers, steps int) []Boid {
+	forceWorkers = workers
+	stepCount = 0
+	loadBoids(initial)
+	for i := 0; i < steps; i++ {
+		stepSimulation()
+	}
+	return snapshotBoids()
+}
+
+func TestParallelForcesMatchSerial(t *testing.T) {
+	params = DefaultSimulationParams()
+	mortonReorderInterval = 3
+	initSimulationFrom(rand.New(rand.NewSource(42)), 3000, 1600.0, 1200.0)
+	initial := snapshotBoids()
+
+	serial := runFlock(initial, 1, 10)
+	for _, workers := range []int{2, 4, 16} {
+		parallel := runFlock(initial, workers, 10)
+		for i := range serial {
+			if parallel[i] != serial[i] {
+				t.Fatalf("workers=%d boid %d = %+v, want %+v", workers, i, parallel[i], serial[i])
+			}
+		}
+	}
+
+	// Reset global state
+	forceWorkers = defaultForceWorkers
+	mortonReorderInterval = defaultMortonReorderInterval
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestParallelForCoversRange(t *testing.T) {
+	for _, n := range []int{0, 1, parallelChunkSize, parallelChunkSize*5 + 3} {
+		var mu sync.Mutex
+		var seen []int
+		parallelFor(n, 4, func(start, end int) {
+			mu.Lock()
+			defer mu.Unlock()
+			for i := start; i < end; i++ {
+				seen = append(seen, i)
+			}
+		})
+
+		sort.Ints(seen)
+		if len(seen) != n {
+			t.Fatalf("parallelFor(%d) visited %d indices, want %d", n, len(seen), n)
+		}
+		for i, v := range seen {
+			if v != i {
+				t.Fatalf("parallelFor(%d) visited %d at position %d", n, v, i)
+			}
+		}
+	}
+}
+
+func TestStepWithBudgetRotatesBoids(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(3)), 1000, 800.0, 600.0)
+	mortonReorderInterval = 0
+	forceCursor = 0
+
+	// Each budgeted step recomputes the next 300 boids, wrapping at the end
+	wantCursors := []int{300, 600, 900, 200}
+	for step, want := range wantCursors {
+		report := stepWithBudget(StepBudget{Boids: 300})
+		if report.Computed != 300 || report.Count != 1000 {
+			t.Fatalf("step %d report = %+v, want 300 of 1000 computed", step, report)
+		}
+		if got := report.Fraction(); got != 0.3 {
+			t.Errorf("step %d Fraction() = %v, want 0.3", step, got)
+		}
+		if forceCursor != want {
+			t.Errorf("step %d forceCursor = %d, want %d", step, forceCursor, want)
+		}
+	}
+
+	// A budget that cannot finish still computes one batch
+	report := stepWithBudget(StepBudget{Time: time.Nanosecond})
+	if report.Computed == 0 || report.Computed > parallelChunkSize*forceWorkers {
+		t.Errorf("tight time budget computed %d boids, want one batch", report.Computed)
+	}
+
+	// Unlimited steps recompute everyone
+	if report := stepWithBudget(StepBudget{}); report.Fraction() != 1 {
+		t.Errorf("unbudgeted Fraction() = %v, want 1", report.Fraction())
+	}
+
+	// Reset global state
+	mortonReorderInterval = defaultMortonReorderInterval
+	forceCursor = 0
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestStepWithBudgetReusesForces(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(5)), 500, 800.0, 600.0)
+	mortonReorderInterval = 0
+	forceCursor = 0
+
+	stepSimulation()
+	before := snapshotBoids()
+	stepWithBudget(StepBudget{Boids: 100})
+
+	// Boids outside the recomputed window keep last step's force
+	for i := 100; i < len(boids); i++ {
+		if boids[i].Steering != before[i].Steering {
+			t.Fatalf("boid %d Steering = %v, want reused %v", i, boids[i].Steering, before[i].Steering)
+		}
+	}
+
+	// Reset global state
+	mortonReorderInterval = defaultMortonReorderInterval
+	forceCursor = 0
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
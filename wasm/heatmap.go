@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// heatmapChannels is the number of values exported per cell:
+// occupancy, mean velocity X, mean velocity Y and mean speed
+const heatmapChannels = 4
+
+// maxHeatmapCells bounds the resolution so a typo cannot allocate gigabytes
+const maxHeatmapCells = 1 << 22
+
+// Heatmap accumulates where boids spend time and how fast they move there
+// on a grid laid over the world
+type Heatmap struct {
+	Cols, Rows int
+	Decay      float64 // fraction of the accumulated values lost each step, 0 keeps everything
+	Steps      int     // steps accumulated since the heatmap was created
+
+	occupancy []float64
+	sumVX     []float64
+	sumVY     []float64
+	sumSpeed  []float64
+}
+
+// heatmap is the active accumulator, nil when disabled
+var heatmap *Heatmap
+
+// NewHeatmap creates an empty heatmap with cols x rows cells
+func NewHeatmap(cols, rows int, decay float64) (*Heatmap, error) {
+	if cols < 1 || rows < 1 || cols*rows > maxHeatmapCells {
+		return nil, fmt.Errorf("heatmap must have between 1 and %d cells, got %dx%d", maxHeatmapCells, cols, rows)
+	}
+	if math.IsNaN(decay) || decay < 0 || decay >= 1 {
+		return nil, fmt.Errorf("heatmap decay must be in [0, 1), got %v", decay)
+	}
+
+	cells := cols * rows
+	return &Heatmap{
+		Cols:      cols,
+		Rows:      rows,
+		Decay:     decay,
+		occupancy: make([]float64, cells),
+		sumVX:     make([]float64, cells),
+		sumVY:     make([]float64, cells),
+		sumSpeed:  make([]float64, cells),
+	}, nil
+}
+
+// accumulate decays the grid and adds every boid of the current snapshot.
+// Cells stretch with the world, and boids outside it in open sky are skipped.
+func (h *Heatmap) accumulate() {
+	if h == nil {
+		return
+	}
+
+	if h.Decay > 0 {
+		keep := 1 - h.Decay
+		for i := range h.occupancy {
+			h.occupancy[i] *= keep
+			h.sumVX[i] *= keep
+			h.sumVY[i] *= keep
+			h.sumSpeed[i] *= keep
+		}
+	}
+
+	scaleX := float64(h.Cols) / worldWidth
+	scaleY := float64(h.Rows) / worldHeight
+	for i := 0; i < flock.Len(); i++ {
+		p := flock.Position(i)
+		col, row := int(math.Floor(p.X*scaleX)), int(math.Floor(p.Y*scaleY))
+		// WrapAround leaves boids exactly on the far edge
+		if col == h.Cols {
+			col--
+		}
+		if row == h.Rows {
+			row--
+		}
+		if col < 0 || col >= h.Cols || row < 0 || row >= h.Rows {
+			continue
+		}
+
+		cell := row*h.Cols + col
+		v := flock.Velocity(i)
+		h.occupancy[cell]++
+		h.sumVX[cell] += v.X
+		h.sumVY[cell] += v.Y
+		h.sumSpeed[cell] += v.Magnitude()
+	}
+	h.Steps++
+}
+
+// Values returns the grid row by row, heatmapChannels values per cell:
+// accumulated occupancy followed by the mean velocity and speed of the
+// boids counted there
+func (h *Heatmap) Values() []float32 {
+	values := make([]float32, len(h.occupancy)*heatmapChannels)
+	for cell, occupancy := range h.occupancy {
+		out := values[cell*heatmapChannels:]
+		out[0] = float32(occupancy)
+		if occupancy > 0 {
+			out[1] = float32(h.sumVX[cell] / occupancy)
+			out[2] = float32(h.sumVY[cell] / occupancy)
+			out[3] = float32(h.sumSpeed[cell] / occupancy)
+		}
+	}
+	return values
+}
+
+// configureHeatmap starts a new heatmap, or turns it off when cols or rows is 0
+func configureHeatmap(cols, rows int, decay float64) error {
+	if cols == 0 || rows == 0 {
+		heatmap = nil
+		return nil
+	}
+
+	h, err := NewHeatmap(cols, rows, decay)
+	if err != nil {
+		return err
+	}
+	heatmap = h
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// heatmapCSV writes the accumulated heatmap when a headless run finishes
+type heatmapCSV struct {
+	path string
+}
+
+// afterStep does nothing; the engine accumulates the heatmap itself
+func (out heatmapCSV) afterStep() error {
+	return nil
+}
+
+// Close writes one row per cell with its column, row and channel values
+func (out heatmapCSV) Close() error {
+	if heatmap == nil {
+		return nil
+	}
+
+	f, err := os.Create(out.path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "col,row,occupancy,meanVX,meanVY,meanSpeed")
+	values := heatmap.Values()
+	for cell := 0; cell < heatmap.Cols*heatmap.Rows; cell++ {
+		v := values[cell*heatmapChannels:]
+		fmt.Fprintf(w, "%d,%d,%g,%g,%g,%g\n", cell%heatmap.Cols, cell/heatmap.Cols, v[0], v[1], v[2], v[3])
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
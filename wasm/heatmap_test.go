@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func TestHeatmapAccumulates(t *testing.T) {
+	worldWidth, worldHeight = 800.0, 600.0
+	precision = Precision64
+	loadBoids([]Boid{
+		{Position: Vector2{X: 10, Y: 10}, Velocity: Vector2{X: 2, Y: 0}},
+		{Position: Vector2{X: 90, Y: 50}, Velocity: Vector2{X: 0, Y: 2}},
+		{Position: Vector2{X: 799, Y: 599}, Velocity: Vector2{X: -1, Y: 0}},
+		{Position: Vector2{X: 800, Y: 600}, Velocity: Vector2{X: -1, Y: 0}}, // on the wrap edge
+		{Position: Vector2{X: -5, Y: 10}, Velocity: Vector2{X: 1, Y: 1}},    // outside the world
+	})
+
+	h, err := NewHeatmap(8, 6, 0)
+	if err != nil {
+		t.Fatalf("NewHeatmap() error: %v", err)
+	}
+	h.accumulate()
+	h.accumulate()
+	values := h.Values()
+
+	// Cell (0, 0) holds the first two boids twice
+	first := values[:heatmapChannels]
+	if first[0] != 4 || first[1] != 1 || first[2] != 1 || first[3] != 2 {
+		t.Errorf("cell (0, 0) = %v, want [4 1 1 2]", first)
+	}
+	last := values[(6*8-1)*heatmapChannels:]
+	if last[0] != 4 || last[1] != -1 {
+		t.Errorf("last cell = %v, want occupancy 4 and mean vx -1", last)
+	}
+
+	total := float32(0)
+	for cell := 0; cell < 8*6; cell++ {
+		total += values[cell*heatmapChannels]
+	}
+	if total != 8 {
+		t.Errorf("total occupancy = %v, want 8", total)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestHeatmapDecay(t *testing.T) {
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{{Position: Vector2{X: 10, Y: 10}, Velocity: Vector2{X: 1, Y: 0}}})
+
+	h, _ := NewHeatmap(4, 4, 0.5)
+	for i := 0; i < 3; i++ {
+		h.accumulate()
+	}
+
+	// 1 * 0.5^2 + 1 * 0.5 + 1
+	if got := h.Values()[0]; got != 1.75 {
+		t.Errorf("decayed occupancy = %v, want 1.75", got)
+	}
+	// The mean velocity is unaffected by decay
+	if got := h.Values()[1]; got != 1 {
+		t.Errorf("decayed mean vx = %v, want 1", got)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestNewHeatmapValidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		cols, rows int
+		decay      float64
+		wantErr    bool
+	}{
+		{"valid", 80, 60, 0.01, false},
+		{"no decay", 1, 1, 0, false},
+		{"zero cols", 0, 60, 0, true},
+		{"too many cells", 1 << 12, 1 << 12, 0, true},
+		{"negative decay", 80, 60, -0.1, true},
+		{"full decay", 80, 60, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHeatmap(tt.cols, tt.rows, tt.decay)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewHeatmap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
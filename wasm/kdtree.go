@@ -0,0 +1,199 @@
+package main
+
+// KDTree is a balanced 2-d tree over the inserted boids. It is stored
+// implicitly: the subtree over order[lo:hi] has its splitting boid at the
+// middle, splitting on X at even depths and on Y at odd depths. The tree is
+// rebuilt lazily on the first query after inserts.
+type KDTree struct {
+	points   []Vector2 // position of each inserted boid, by boid index
+	inserted []int
+	order    []int
+	dirty    bool
+}
+
+// NewKDTree creates an empty k-d tree
+func NewKDTree() *KDTree {
+	return &KDTree{}
+}
+
+// Clear removes every boid from the tree
+func (kd *KDTree) Clear() {
+	kd.inserted = kd.inserted[:0]
+	kd.dirty = true
+}
+
+// Insert adds a boid to the tree
+func (kd *KDTree) Insert(boidIndex int, position Vector2) {
+	kd.points = setPoint(kd.points, boidIndex, position)
+	kd.inserted = append(kd.inserted, boidIndex)
+	kd.dirty = true
+}
+
+// GetNeighbors returns the boid indices within radius
+func (kd *KDTree) GetNeighbors(position Vector2, radius float64) []int {
+	return kd.QueryRadius(position, radius)
+}
+
+// QueryRadius returns the boid indices strictly within radius
+func (kd *KDTree) QueryRadius(position Vector2, radius float64) []int {
+	kd.Build()
+	result := make([]int, 0, 20)
+	return kd.queryRadius(0, len(kd.order), 0, position, radius, result)
+}
+
+func (kd *KDTree) queryRadius(lo, hi, depth int, position Vector2, radius float64, result []int) []int {
+	if lo >= hi {
+		return result
+	}
+
+	mid := (lo + hi) / 2
+	index := kd.order[mid]
+	if kd.points[index].DistanceSquared(position) < radius*radius {
+		result = append(result, index)
+	}
+
+	diff := kdAxis(position, depth) - kdAxis(kd.points[index], depth)
+	if diff-radius <= 0 {
+		result = kd.queryRadius(lo, mid, depth+1, position, radius, result)
+	}
+	if diff+radius >= 0 {
+		result = kd.queryRadius(mid+1, hi, depth+1, position, radius, result)
+	}
+	return result
+}
+
+// KNearest returns up to k boid indices ordered by increasing distance
+func (kd *KDTree) KNearest(position Vector2, k int) []int {
+	kd.Build()
+	if k <= 0 || len(kd.order) == 0 {
+		return nil
+	}
+
+	best := newNearestSet(k)
+	kd.nearest(0, len(kd.order), 0, position, best)
+	return best.indices()
+}
+
+// nearest searches the near side of each split first and the far side only
+// when the splitting line is closer than the current k-th neighbor
+func (kd *KDTree) nearest(lo, hi, depth int, position Vector2, best *nearestSet) {
+	if lo >= hi {
+		return
+	}
+
+	mid := (lo + hi) / 2
+	index := kd.order[mid]
+	best.offer(index, kd.points[index].DistanceSquared(position))
+
+	diff := kdAxis(position, depth) - kdAxis(kd.points[index], depth)
+	if diff < 0 {
+		kd.nearest(lo, mid, depth+1, position, best)
+		if diff*diff <= best.worst() {
+			kd.nearest(mid+1, hi, depth+1, position, best)
+		}
+	} else {
+		kd.nearest(mid+1, hi, depth+1, position, best)
+		if diff*diff <= best.worst() {
+			kd.nearest(lo, mid, depth+1, position, best)
+		}
+	}
+}
+
+// QueryRect returns the boid indices inside the rectangle
+func (kd *KDTree) QueryRect(rect Rect) []int {
+	kd.Build()
+	result := make([]int, 0, 64)
+	return kd.queryRect(0, len(kd.order), 0, rect, result)
+}
+
+func (kd *KDTree) queryRect(lo, hi, depth int, rect Rect, result []int) []int {
+	if lo >= hi {
+		return result
+	}
+
+	mid := (lo + hi) / 2
+	index := kd.order[mid]
+	p := kd.points[index]
+	if rect.Contains(p) {
+		result = append(result, index)
+	}
+
+	rectMin, rectMax := rect.X, rect.X+rect.Width
+	if depth%2 == 1 {
+		rectMin, rectMax = rect.Y, rect.Y+rect.Height
+	}
+	split := kdAxis(p, depth)
+	if rectMin <= split {
+		result = kd.queryRect(lo, mid, depth+1, rect, result)
+	}
+	if rectMax >= split {
+		result = kd.queryRect(mid+1, hi, depth+1, rect, result)
+	}
+	return result
+}
+
+// Build rebuilds the tree from the inserted boids if anything changed
+func (kd *KDTree) Build() {
+	if !kd.dirty {
+		return
+	}
+	kd.dirty = false
+	kd.order = append(kd.order[:0], kd.inserted...)
+	kd.buildRange(0, len(kd.order), 0)
+}
+
+// buildRange places the median of order[lo:hi] on the splitting axis at the middle
+func (kd *KDTree) buildRange(lo, hi, depth int) {
+	if hi-lo <= 1 {
+		return
+	}
+
+	mid := (lo + hi) / 2
+	selectNth(kd.order[lo:hi], mid-lo, func(index int) float64 {
+		return kdAxis(kd.points[index], depth)
+	})
+	kd.buildRange(lo, mid, depth+1)
+	kd.buildRange(mid+1, hi, depth+1)
+}
+
+// kdAxis returns the coordinate a tree level splits on
+func kdAxis(p Vector2, depth int) float64 {
+	if depth%2 == 0 {
+		return p.X
+	}
+	return p.Y
+}
+
+// selectNth reorders items so items[n] holds the element that would be there
+// if sorted by key, with smaller keys before it and larger ones after. A
+// three-way partition keeps boids stacked on one coordinate from going quadratic.
+func selectNth(items []int, n int, key func(int) float64) {
+	lo, hi := 0, len(items)-1
+	for lo < hi {
+		pivot := key(items[(lo+hi)/2])
+		lt, i, gt := lo, lo, hi
+		for i <= gt {
+			k := key(items[i])
+			switch {
+			case k < pivot:
+				items[lt], items[i] = items[i], items[lt]
+				lt++
+				i++
+			case k > pivot:
+				items[i], items[gt] = items[gt], items[i]
+				gt--
+			default:
+				i++
+			}
+		}
+
+		switch {
+		case n < lt:
+			hi = lt - 1
+		case n > gt:
+			lo = gt + 1
+		default:
+			return
+		}
+	}
+}
@@ -3,130 +3,912 @@
 package main
 
 import (
-	"math/rand"
+	"fmt"
+	"math"
+	"strings"
 	"syscall/js"
 	"time"
+	"unsafe"
 )
 
+// jsError wraps an error in an object so JavaScript callers can check result.error
+func jsError(err error) interface{} {
+	return map[string]interface{}{"error": err.Error()}
+}
+
 // JavaScript exports
 func initializeSimulation(this js.Value, args []js.Value) interface{} {
 	boidCount := args[0].Int()
 	width := args[1].Float()
 	height := args[2].Float()
 
-	canvasWidth = width
-	canvasHeight = height
-	boids = make([]Boid, 0, boidCount)
+	// The world defaults to the canvas size unless a larger one is given
+	worldW, worldH := width, height
+	if len(args) > 4 {
+		worldW = floatOr(args[3], width)
+		worldH = floatOr(args[4], height)
+	}
 
-	// Initialize spatial grid with optimal cell size
-	cellSize := 75.0 // Slightly larger than max interaction radius
-	spatialGrid = NewSpatialGrid(width, height, cellSize)
+	initSimulation(boidCount, worldW, worldH)
+	camera = NewCamera(width, height)
+
+	return nil
+}
+
+// updateSimulation steps the flock. An optional budget, either milliseconds or
+// {timeMs, maxBoids}, limits how many boids get fresh forces this frame.
+func updateSimulation(this js.Value, args []js.Value) interface{} {
+	var budget StepBudget
+	if len(args) > 0 {
+		switch args[0].Type() {
+		case js.TypeNumber:
+			budget.Time = millisToDuration(args[0].Float())
+		case js.TypeObject:
+			budget.Time = millisToDuration(floatOr(args[0].Get("timeMs"), 0))
+			budget.Boids = int(floatOr(args[0].Get("maxBoids"), 0))
+		}
+	}
 
-	rand.Seed(time.Now().UnixNano())
+	report := stepWithBudget(budget)
+	deliverFlockEvents()
+	return map[string]interface{}{
+		"fraction":  report.Fraction(),
+		"computed":  report.Computed,
+		"boidCount": report.Count,
+		"elapsedMs": float64(report.Elapsed) / float64(time.Millisecond),
+	}
+}
 
-	for i := 0; i < boidCount; i++ {
-		x := rand.Float64() * canvasWidth
-		y := rand.Float64() * canvasHeight
-		boids = append(boids, NewBoid(x, y))
+// millisToDuration converts a JS millisecond value, treating non-positive as unlimited
+func millisToDuration(ms float64) time.Duration {
+	if !(ms > 0) {
+		return 0
 	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
 
+func setMousePosition(this js.Value, args []js.Value) interface{} {
+	// The mouse is reported in canvas pixels; forces work in world coordinates
+	mousePos = camera.ScreenToWorld(Vector2{X: args[0].Float(), Y: args[1].Float()})
 	return nil
 }
 
-func updateSimulation(this js.Value, args []js.Value) interface{} {
-	// Clear and rebuild spatial grid
-	spatialGrid.Clear()
-	for i := range boids {
-		spatialGrid.Insert(i, boids[i].Position)
+func getBoidCount(this js.Value, args []js.Value) interface{} {
+	return len(boids)
+}
+
+
+// updateParamsFromArgs applies the named parameters from positional
+// arguments with the same range checks as setParams
+func updateParamsFromArgs(args []js.Value, names ...string) interface{} {
+	if len(args) < len(names) {
+		return jsError(fmt.Errorf("expected %s", strings.Join(names, " and ")))
+	}
+	updates := make(map[string]float64, len(names))
+	for i, name := range names {
+		if args[i].Type() != js.TypeNumber {
+			return jsError(fmt.Errorf("parameter %q must be a number", name))
+		}
+		updates[name] = args[i].Float()
 	}
+	if _, err := applyParams(updates); err != nil {
+		return jsError(err)
+	}
+	return nil
+}
 
-	// Update each boid
-	for i := range boids {
-		boid := &boids[i]
+func updateSeparationParams(this js.Value, args []js.Value) interface{} {
+	return updateParamsFromArgs(args, "separationRadius", "separationStrength")
+}
 
-		// Calculate flocking forces using spatial grid
-		separation := boid.separate(i)
-		alignment := boid.align(i)
-		cohesionForce := boid.cohesion(i)
-		mouseAvoidance := boid.avoidMouse()
+func updateAlignmentParams(this js.Value, args []js.Value) interface{} {
+	return updateParamsFromArgs(args, "alignmentRadius", "alignmentStrength")
+}
 
-		// Apply forces
-		boid.ApplyForce(separation)
-		boid.ApplyForce(alignment)
-		boid.ApplyForce(cohesionForce)
-		boid.ApplyForce(mouseAvoidance)
+func updateCohesionParams(this js.Value, args []js.Value) interface{} {
+	return updateParamsFromArgs(args, "cohesionRadius", "cohesionStrength")
+}
 
-		// Update position
-		boid.Update()
+func updateMouseAvoidanceDistance(this js.Value, args []js.Value) interface{} {
+	return updateParamsFromArgs(args, "mouseAvoidanceDistance")
+}
 
-		// Handle boundaries
-		boid.WrapAround(canvasWidth, canvasHeight)
+func setParams(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError(fmt.Errorf("setParams expects an object"))
 	}
 
-	return nil
+	updates := make(map[string]float64)
+	keys := js.Global().Get("Object").Call("keys", args[0])
+	for i := 0; i < keys.Length(); i++ {
+		key := keys.Index(i).String()
+		value := args[0].Get(key)
+		if value.Type() != js.TypeNumber {
+			return jsError(fmt.Errorf("parameter %q must be a number", key))
+		}
+		updates[key] = value.Float()
+	}
+
+	result, err := applyParams(updates)
+	if err != nil {
+		return jsError(err)
+	}
+	return paramsToMap(result)
 }
 
-func setMousePosition(this js.Value, args []js.Value) interface{} {
-	mousePos.X = args[0].Float()
-	mousePos.Y = args[1].Float()
-	return nil
+func addBoids(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError(fmt.Errorf("addBoids expects a count"))
+	}
+
+	spec := SpawnSpec{Mode: SpawnRandom}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		var err error
+		if spec, err = spawnSpecFromJS(args[1]); err != nil {
+			return jsError(err)
+		}
+	}
+
+	ids, err := appendBoids(args[0].Int(), spec)
+	if err != nil {
+		return jsError(err)
+	}
+	return intsToJS(ids)
 }
 
-func getBoidCount(this js.Value, args []js.Value) interface{} {
+func removeBoids(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError(fmt.Errorf("removeBoids expects a count or an array of boid IDs"))
+	}
+
+	var err error
+	if args[0].InstanceOf(js.Global().Get("Array")) {
+		ids := make([]int, args[0].Length())
+		for i := range ids {
+			ids[i] = args[0].Index(i).Int()
+		}
+		err = deleteBoidsByID(ids)
+	} else {
+		err = trimBoids(args[0].Int())
+	}
+	if err != nil {
+		return jsError(err)
+	}
 	return len(boids)
 }
 
+func resizeWorld(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError(fmt.Errorf("resizeWorld expects width and height"))
+	}
 
-func updateSeparationParams(this js.Value, args []js.Value) interface{} {
-	params.SeparationRadius = args[0].Float()
-	params.SeparationStrength = args[1].Float()
+	policy := ResizeKeep
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		policy = ResizePolicy(args[2].String())
+	}
+
+	if err := setWorldSize(args[0].Float(), args[1].Float(), policy); err != nil {
+		return jsError(err)
+	}
 	return nil
 }
 
-func updateAlignmentParams(this js.Value, args []js.Value) interface{} {
-	params.AlignmentRadius = args[0].Float()
-	params.AlignmentStrength = args[1].Float()
+func setBoundaryMode(this js.Value, args []js.Value) interface{} {
+	if err := changeBoundaryMode(BoundaryMode(args[0].String())); err != nil {
+		return jsError(err)
+	}
 	return nil
 }
 
-func updateCohesionParams(this js.Value, args []js.Value) interface{} {
-	params.CohesionRadius = args[0].Float()
-	params.CohesionStrength = args[1].Float()
+func setSpatialIndex(this js.Value, args []js.Value) interface{} {
+	if err := changeSpatialIndex(SpatialIndexKind(args[0].String())); err != nil {
+		return jsError(err)
+	}
 	return nil
 }
 
-func updateMouseAvoidanceDistance(this js.Value, args []js.Value) interface{} {
-	params.MouseAvoidanceDistance = args[0].Float()
+func setReorderInterval(this js.Value, args []js.Value) interface{} {
+	steps := args[0].Int()
+	if steps < 0 {
+		return jsError(fmt.Errorf("reorder interval must not be negative"))
+	}
+	mortonReorderInterval = steps
+	return nil
+}
+
+func setPrecision(this js.Value, args []js.Value) interface{} {
+	if err := changePrecision(Precision(args[0].String())); err != nil {
+		return jsError(err)
+	}
 	return nil
 }
 
+func getBoidIndex(this js.Value, args []js.Value) interface{} {
+	index, ok := lookupBoidIndex(args[0].Int())
+	if !ok {
+		return -1
+	}
+	return index
+}
+
+// intsToJS converts a slice of ints to a JavaScript array
+func intsToJS(values []int) interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// spawnSpecFromJS reads {mode, x, y, width, height, vx, vy} into a SpawnSpec
+func spawnSpecFromJS(v js.Value) (SpawnSpec, error) {
+	spec := SpawnSpec{Mode: SpawnRandom}
+	if mode := v.Get("mode"); mode.Type() == js.TypeString {
+		spec.Mode = SpawnMode(mode.String())
+	}
+	spec.X = floatOr(v.Get("x"), 0)
+	spec.Y = floatOr(v.Get("y"), 0)
+	spec.Width = floatOr(v.Get("width"), 0)
+	spec.Height = floatOr(v.Get("height"), 0)
+
+	vx, vy := v.Get("vx"), v.Get("vy")
+	if vx.Type() == js.TypeNumber || vy.Type() == js.TypeNumber {
+		spec.Velocity = &Vector2{X: floatOr(vx, 0), Y: floatOr(vy, 0)}
+	}
+
+	return spec, spec.Validate()
+}
+
+// floatOr returns v as a float, or fallback when v is not a number
+func floatOr(v js.Value, fallback float64) float64 {
+	if v.Type() != js.TypeNumber {
+		return fallback
+	}
+	return v.Float()
+}
+
+func setCamera(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError(fmt.Errorf("setCamera expects an object"))
+	}
+
+	next := camera
+	next.X = floatOr(args[0].Get("x"), next.X)
+	next.Y = floatOr(args[0].Get("y"), next.Y)
+	next.Zoom = floatOr(args[0].Get("zoom"), next.Zoom)
+	next.Width = floatOr(args[0].Get("width"), next.Width)
+	next.Height = floatOr(args[0].Get("height"), next.Height)
+	if err := next.Validate(); err != nil {
+		return jsError(err)
+	}
+
+	camera = next
+	return cameraToJS(camera)
+}
+
+func getCamera(this js.Value, args []js.Value) interface{} {
+	return cameraToJS(camera)
+}
+
+func panCamera(this js.Value, args []js.Value) interface{} {
+	camera.Pan(args[0].Float(), args[1].Float())
+	return cameraToJS(camera)
+}
+
+func zoomCamera(this js.Value, args []js.Value) interface{} {
+	// Zoom around the canvas centre unless an anchor is given
+	anchor := Vector2{X: camera.Width / 2, Y: camera.Height / 2}
+	if len(args) > 2 {
+		anchor = Vector2{X: args[1].Float(), Y: args[2].Float()}
+	}
+
+	if err := camera.ZoomAt(args[0].Float(), anchor); err != nil {
+		return jsError(err)
+	}
+	return cameraToJS(camera)
+}
+
+// cameraToJS converts the camera and its visible world rectangle to a JS object
+func cameraToJS(c Camera) interface{} {
+	view := c.ViewRect()
+	return map[string]interface{}{
+		"x":      c.X,
+		"y":      c.Y,
+		"zoom":   c.Zoom,
+		"width":  c.Width,
+		"height": c.Height,
+		"view":   rectToJS(view),
+	}
+}
+
+// rectToJS converts a rectangle to {x, y, width, height}
+func rectToJS(r Rect) interface{} {
+	return map[string]interface{}{
+		"x":      r.X,
+		"y":      r.Y,
+		"width":  r.Width,
+		"height": r.Height,
+	}
+}
+
+// rectFromJS reads {x, y, width, height}, falling back to fallback for missing fields
+func rectFromJS(v js.Value, fallback Rect) Rect {
+	return Rect{
+		X:      floatOr(v.Get("x"), fallback.X),
+		Y:      floatOr(v.Get("y"), fallback.Y),
+		Width:  floatOr(v.Get("width"), fallback.Width),
+		Height: floatOr(v.Get("height"), fallback.Height),
+	}
+}
+
+func getParams(this js.Value, args []js.Value) interface{} {
+	return paramsToMap(params)
+}
+
+func getConfig(this js.Value, args []js.Value) interface{} {
+	return engineConfig()
+}
+
 // Batch API for efficient data retrieval
 func getAllBoidData(this js.Value, args []js.Value) interface{} {
+	defer phaseEnd(PhaseExport, phaseStart())
 	result := js.Global().Get("Array").New(len(boids))
 	
-	for i, boid := range boids {
-		boidData := js.Global().Get("Object").New()
-		boidData.Set("x", boid.Position.X)
-		boidData.Set("y", boid.Position.Y)
-		boidData.Set("vx", boid.Velocity.X)
-		boidData.Set("vy", boid.Velocity.Y)
-		result.SetIndex(i, boidData)
+	for i := range boids {
+		b := boidAt(i)
+		result.SetIndex(i, boidToJS(&b))
 	}
 	
 	return result
 }
 
+// getVisibleBoids returns only the boids inside viewRect, or inside the camera view when omitted
+func getVisibleBoids(this js.Value, args []js.Value) interface{} {
+	defer phaseEnd(PhaseExport, phaseStart())
+	view := camera.ViewRect()
+	if len(args) > 0 && args[0].Type() == js.TypeObject {
+		view = rectFromJS(args[0], view)
+	}
+
+	visible := visibleBoidIndices(view)
+	result := js.Global().Get("Array").New(len(visible))
+	for i, index := range visible {
+		b := boidAt(index)
+		result.SetIndex(i, boidToJS(&b))
+	}
+	return result
+}
+
+// getBoidBuffers returns positions and velocities as typed arrays copied
+// straight from the engine's structure-of-arrays storage: Float32Array in
+// float32 precision mode, Float64Array otherwise
+func getBoidBuffers(this js.Value, args []js.Value) interface{} {
+	defer phaseEnd(PhaseExport, phaseStart())
+	result := map[string]interface{}{
+		"count":     flock.Len(),
+		"precision": string(precision),
+	}
+	if precision == Precision32 {
+		result["x"] = float32ArrayToJS(flock.X32)
+		result["y"] = float32ArrayToJS(flock.Y32)
+		result["vx"] = float32ArrayToJS(flock.VX32)
+		result["vy"] = float32ArrayToJS(flock.VY32)
+	} else {
+		result["x"] = float64ArrayToJS(flock.X)
+		result["y"] = float64ArrayToJS(flock.Y)
+		result["vx"] = float64ArrayToJS(flock.VX)
+		result["vy"] = float64ArrayToJS(flock.VY)
+	}
+
+	labels := make([]int32, len(boids))
+	for i := range boids {
+		labels[i] = int32(boids[i].Cluster)
+	}
+	result["cluster"] = int32ArrayToJS(labels)
+	return result
+}
+
+// getClusters detects flocks now, labeling every boid, and returns {step, count, sizes, unlabeled}
+func getClusters(this js.Value, args []js.Value) interface{} {
+	result := clustersToMap(detectClusters())
+	deliverFlockEvents()
+	return result
+}
+
+// flockEventCallback receives flock events after each step when set by onFlockEvents
+var flockEventCallback js.Value
+
+// onFlockEvents subscribes a callback to flock events; null unsubscribes and
+// leaves events queued for pollFlockEvents
+func onFlockEvents(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeFunction {
+		flockEventCallback = js.Undefined()
+		return nil
+	}
+	flockEventCallback = args[0]
+	deliverFlockEvents()
+	return nil
+}
+
+// pollFlockEvents returns the queued flock events and clears the queue
+func pollFlockEvents(this js.Value, args []js.Value) interface{} {
+	return flockEventsToJS(drainFlockEvents())
+}
+
+// deliverFlockEvents passes queued events to the subscribed callback, if any
+func deliverFlockEvents() {
+	if flockEventCallback.Type() != js.TypeFunction || len(flockEvents) == 0 {
+		return
+	}
+	flockEventCallback.Invoke(flockEventsToJS(drainFlockEvents()))
+}
+
+func flockEventsToJS(events []FlockEvent) interface{} {
+	result := make([]interface{}, len(events))
+	for i, e := range events {
+		result[i] = flockEventToMap(e)
+	}
+	return result
+}
+
+// setClusterOptions updates {radius, minSize, interval}; omitted fields keep their value
+func setClusterOptions(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError(fmt.Errorf("setClusterOptions expects an options object"))
+	}
+
+	o := clusterOptions
+	o.Radius = floatOr(args[0].Get("radius"), o.Radius)
+	o.MinSize = int(floatOr(args[0].Get("minSize"), float64(o.MinSize)))
+	o.Interval = int(floatOr(args[0].Get("interval"), float64(o.Interval)))
+	if err := changeClusterOptions(o); err != nil {
+		return jsError(err)
+	}
+	return nil
+}
+
+// setStatsHistoryLength sets how many steps of statistics are kept; 0 turns recording off
+func setStatsHistoryLength(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError(fmt.Errorf("setStatsHistoryLength expects a length"))
+	}
+	if err := changeStatsHistoryLength(args[0].Int()); err != nil {
+		return jsError(err)
+	}
+	return nil
+}
+
+// getStatsHistory returns the recorded statistics from sinceStep on as one
+// Float64Array of rows in the order given by fields, averaged down to at
+// most maxPoints rows when given
+func getStatsHistory(this js.Value, args []js.Value) interface{} {
+	sinceStep, maxPoints := 0, 0
+	if len(args) > 0 {
+		sinceStep = int(floatOr(args[0], 0))
+	}
+	if len(args) > 1 {
+		maxPoints = int(floatOr(args[1], 0))
+	}
+
+	records := downsampleStats(statsHistory.since(sinceStep), maxPoints)
+	fields := make([]interface{}, len(statsFields))
+	for i, name := range statsFields {
+		fields[i] = name
+	}
+	return map[string]interface{}{
+		"fields":     fields,
+		"stride":     len(statsFields),
+		"rows":       len(records),
+		"latestStep": stepCount,
+		"data":       float64ArrayToJS(flattenStats(records)),
+	}
+}
+
+// setHeatmap starts accumulating a {cols, rows, decay} heatmap; cols or rows of 0 turns it off
+func setHeatmap(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError(fmt.Errorf("setHeatmap expects an options object"))
+	}
+
+	cols := int(floatOr(args[0].Get("cols"), 0))
+	rows := int(floatOr(args[0].Get("rows"), 0))
+	if err := configureHeatmap(cols, rows, floatOr(args[0].Get("decay"), 0)); err != nil {
+		return jsError(err)
+	}
+	return nil
+}
+
+// getHeatmap returns the accumulated grid as a Float32Array of rows, with
+// occupancy, mean vx, mean vy and mean speed for every cell
+func getHeatmap(this js.Value, args []js.Value) interface{} {
+	if heatmap == nil {
+		return jsError(fmt.Errorf("heatmap is not enabled"))
+	}
+	return map[string]interface{}{
+		"cols":     heatmap.Cols,
+		"rows":     heatmap.Rows,
+		"channels": heatmapChannels,
+		"steps":    heatmap.Steps,
+		"data":     float32ArrayToJS(heatmap.Values()),
+	}
+}
+
+// setTrailLength keeps the last length positions of every boid; 0 turns trails off
+func setTrailLength(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError(fmt.Errorf("setTrailLength expects a length"))
+	}
+	if err := configureTrails(args[0].Int()); err != nil {
+		return jsError(err)
+	}
+	return nil
+}
+
+// getTrails returns {length, count, data}, where data holds length x, y
+// pairs per boid in getBoidBuffers order, oldest first, NaN where the trail breaks
+func getTrails(this js.Value, args []js.Value) interface{} {
+	defer phaseEnd(PhaseExport, phaseStart())
+
+	if trails == nil {
+		return jsError(fmt.Errorf("trails are not enabled"))
+	}
+	return map[string]interface{}{
+		"length": trails.Length,
+		"count":  len(boids),
+		"data":   float32ArrayToJS(trails.Values()),
+	}
+}
+
+// setForceDebug turns recording of per-rule force breakdowns on or off
+func setForceDebug(this js.Value, args []js.Value) interface{} {
+	changeForceDebug(len(args) > 0 && args[0].Truthy())
+	return nil
+}
+
+// getForceBreakdown returns the force terms and neighbor counts of one boid by ID
+func getForceBreakdown(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError(fmt.Errorf("getForceBreakdown expects a boid id"))
+	}
+	index, ok := lookupBoidIndex(args[0].Int())
+	if !ok {
+		return jsError(fmt.Errorf("unknown boid id %d", args[0].Int()))
+	}
+	fb, ok := lookupForceBreakdown(index)
+	if !ok {
+		return jsError(fmt.Errorf("no force breakdown recorded for boid %d", args[0].Int()))
+	}
+	return forceBreakdownToMap(fb)
+}
+
+// getForceBreakdowns returns every boid's breakdown as one Float32Array,
+// a row per boid in getBoidBuffers order, NaN where none was recorded
+func getForceBreakdowns(this js.Value, args []js.Value) interface{} {
+	defer phaseEnd(PhaseExport, phaseStart())
+
+	fields := make([]interface{}, len(forceFields))
+	for i, name := range forceFields {
+		fields[i] = name
+	}
+	return map[string]interface{}{
+		"fields": fields,
+		"stride": len(forceFields),
+		"count":  len(boids),
+		"data":   float32ArrayToJS(flattenForceBreakdowns()),
+	}
+}
+
+// getBoidNeighbors returns the IDs and distances of the boids each rule uses
+// for the boid with the given ID, nearest first
+func getBoidNeighbors(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError(fmt.Errorf("getBoidNeighbors expects a boid id"))
+	}
+	id := args[0].Int()
+	index, ok := lookupBoidIndex(id)
+	if !ok {
+		return jsError(fmt.Errorf("unknown boid id %d", id))
+	}
+	return neighborsToMap(id, inspectNeighbors(index))
+}
+
+// nearestBoid(x, y, maxDist?) returns {id, index, distance} of the closest
+// boid, or null when none is strictly closer than maxDist. Like the other
+// spatial queries it takes world coordinates, not canvas pixels; unlike
+// setMousePosition it does not go through the camera.
+func nearestBoid(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError(fmt.Errorf("nearestBoid expects x and y"))
+	}
+	maxDist := 0.0
+	if len(args) > 2 {
+		maxDist = floatOr(args[2], 0)
+	}
+
+	index, distance, ok := findNearestBoid(Vector2{X: args[0].Float(), Y: args[1].Float()}, maxDist)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"id":       boids[index].ID,
+		"index":    index,
+		"distance": distance,
+	}
+}
+
+// boidsInCircle(x, y, radius) returns the IDs of boids within radius as an
+// Int32Array. Center and radius are in world units.
+func boidsInCircle(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError(fmt.Errorf("boidsInCircle expects x, y and radius"))
+	}
+	indices, err := queryBoidsInCircle(Vector2{X: args[0].Float(), Y: args[1].Float()}, args[2].Float())
+	if err != nil {
+		return jsError(err)
+	}
+	return int32ArrayToJS(boidIDsAt(indices))
+}
+
+// boidsInRect({x, y, width, height}) returns the IDs of boids inside the
+// rectangle, given in world coordinates, as an Int32Array
+func boidsInRect(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError(fmt.Errorf("boidsInRect expects a rectangle object"))
+	}
+	indices, err := queryBoidsInRect(rectFromJS(args[0], Rect{}))
+	if err != nil {
+		return jsError(err)
+	}
+	return int32ArrayToJS(boidIDsAt(indices))
+}
+
+// raycast(x1, y1, x2, y2, hitRadius?) returns {id, index, distance, t, x, y}
+// for the first boid along the segment, or null when it hits none. The
+// segment, hitRadius and the returned hit are in world coordinates.
+func raycast(this js.Value, args []js.Value) interface{} {
+	if len(args) < 4 {
+		return jsError(fmt.Errorf("raycast expects x1, y1, x2 and y2"))
+	}
+	hitRadius := defaultHitRadius
+	if len(args) > 4 {
+		hitRadius = floatOr(args[4], defaultHitRadius)
+	}
+
+	from := Vector2{X: args[0].Float(), Y: args[1].Float()}
+	to := Vector2{X: args[2].Float(), Y: args[3].Float()}
+	hit, ok, err := castRay(from, to, hitRadius)
+	if err != nil {
+		return jsError(err)
+	}
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"id":       boids[hit.Index].ID,
+		"index":    hit.Index,
+		"distance": hit.Distance,
+		"t":        hit.T,
+		"x":        hit.Point.X,
+		"y":        hit.Point.Y,
+	}
+}
+
+// getMetrics returns collective-motion metrics of the current flock
+func getMetrics(this js.Value, args []js.Value) interface{} {
+	return metricsToMap(computeFlockMetrics())
+}
+
+// setProfiling turns the engine's per-phase counters on or off, clearing them
+func setProfiling(this js.Value, args []js.Value) interface{} {
+	changeProfiling(len(args) > 0 && args[0].Truthy())
+	return nil
+}
+
+// getProfile returns the counters accumulated since profiling was enabled,
+// clearing them afterwards when called with true
+func getProfile(this js.Value, args []js.Value) interface{} {
+	result := profileToMap(profile)
+	if len(args) > 0 && args[0].Truthy() {
+		resetProfile()
+	}
+	return result
+}
+
+// startTrace begins recording step phases, keeping at most maxEvents events
+func startTrace(this js.Value, args []js.Value) interface{} {
+	limit := 0
+	if len(args) > 0 {
+		limit = int(floatOr(args[0], 0))
+	}
+	startTracing(limit)
+	return nil
+}
+
+// stopTrace ends the trace and returns it as Trace Event Format JSON,
+// ready to save and open in chrome://tracing or Perfetto
+func stopTrace(this js.Value, args []js.Value) interface{} {
+	trace := stopTracing()
+	if trace == nil {
+		return jsError(fmt.Errorf("no trace is running"))
+	}
+
+	var buf strings.Builder
+	if err := trace.WriteJSON(&buf); err != nil {
+		return jsError(err)
+	}
+	return buf.String()
+}
+
+// setDomain restricts this instance to the strip [minX, maxX) of the world.
+// The halo defaults to the largest interaction radius.
+func setDomain(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError(fmt.Errorf("setDomain expects minX and maxX"))
+	}
+
+	d := Domain{MinX: args[0].Float(), MaxX: args[1].Float(), Halo: interactionRadius()}
+	if len(args) > 2 {
+		d.Halo = floatOr(args[2], d.Halo)
+	}
+	if err := assignDomain(d); err != nil {
+		return jsError(err)
+	}
+	return len(boids)
+}
+
+func clearDomain(this js.Value, args []js.Value) interface{} {
+	releaseDomain()
+	return nil
+}
+
+// getHaloBoids returns the owned boids neighboring instances need before the next step
+func getHaloBoids(this js.Value, args []js.Value) interface{} {
+	return boidsToJS(domainHalo())
+}
+
+// getEmigrants removes and returns the boids that left this instance's strip
+func getEmigrants(this js.Value, args []js.Value) interface{} {
+	return boidsToJS(takeEmigrants())
+}
+
+// importBoids takes ownership of boids sent by other instances
+func importBoids(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || !args[0].InstanceOf(js.Global().Get("Array")) {
+		return jsError(fmt.Errorf("importBoids expects an array of boids"))
+	}
+	list, err := boidsFromJS(args[0])
+	if err != nil {
+		return jsError(fmt.Errorf("importBoids: %w", err))
+	}
+	accepted, err := adoptBoids(list)
+	if err != nil {
+		return jsError(fmt.Errorf("importBoids: %w", err))
+	}
+	return accepted
+}
+
+// importHalo adds neighbor boids as ghosts for the next step only
+func importHalo(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || !args[0].InstanceOf(js.Global().Get("Array")) {
+		return jsError(fmt.Errorf("importHalo expects an array of boids"))
+	}
+	list, err := boidsFromJS(args[0])
+	if err != nil {
+		return jsError(fmt.Errorf("importHalo: %w", err))
+	}
+	return addGhosts(list)
+}
+
+// float64ArrayToJS copies values into a new Float64Array byte for byte
+func float64ArrayToJS(values []float64) js.Value {
+	array := js.Global().Get("Float64Array").New(len(values))
+	if len(values) > 0 {
+		bytes := unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), len(values)*8)
+		js.CopyBytesToJS(js.Global().Get("Uint8Array").New(array.Get("buffer")), bytes)
+	}
+	return array
+}
+
+// float32ArrayToJS copies values into a new Float32Array byte for byte
+func float32ArrayToJS(values []float32) js.Value {
+	array := js.Global().Get("Float32Array").New(len(values))
+	if len(values) > 0 {
+		bytes := unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), len(values)*4)
+		js.CopyBytesToJS(js.Global().Get("Uint8Array").New(array.Get("buffer")), bytes)
+	}
+	return array
+}
+
+// int32ArrayToJS copies values into a new Int32Array byte for byte
+func int32ArrayToJS(values []int32) js.Value {
+	array := js.Global().Get("Int32Array").New(len(values))
+	if len(values) > 0 {
+		bytes := unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), len(values)*4)
+		js.CopyBytesToJS(js.Global().Get("Uint8Array").New(array.Get("buffer")), bytes)
+	}
+	return array
+}
+
+// boidToJS converts a boid to the {id, x, y, vx, vy, cluster, flock} object used by the batch APIs
+func boidToJS(boid *Boid) js.Value {
+	boidData := js.Global().Get("Object").New()
+	boidData.Set("id", boid.ID)
+	boidData.Set("x", boid.Position.X)
+	boidData.Set("y", boid.Position.Y)
+	boidData.Set("vx", boid.Velocity.X)
+	boidData.Set("vy", boid.Velocity.Y)
+	boidData.Set("cluster", boid.Cluster)
+	boidData.Set("flock", boid.Flock)
+	return boidData
+}
+
+// numberField reads a finite number property of v. A missing optional
+// property reads as 0.
+func numberField(v js.Value, name string, optional bool) (float64, error) {
+	field := v.Get(name)
+	if optional && field.IsUndefined() {
+		return 0, nil
+	}
+	if field.Type() != js.TypeNumber || math.IsNaN(field.Float()) || math.IsInf(field.Float(), 0) {
+		return 0, fmt.Errorf("%q must be a finite number", name)
+	}
+	return field.Float(), nil
+}
+
+// boidFromJS reads a boid in the format produced by boidToJS. id, x and y are
+// required; vx and vy default to 0.
+func boidFromJS(v js.Value) (Boid, error) {
+	if v.Type() != js.TypeObject {
+		return Boid{}, fmt.Errorf("expected an object, got %s", v.Type())
+	}
+	id, err := numberField(v, "id", false)
+	if err != nil {
+		return Boid{}, err
+	}
+	if id != math.Trunc(id) {
+		return Boid{}, fmt.Errorf("\"id\" must be an integer, got %v", id)
+	}
+	var values [4]float64
+	for i, name := range []string{"x", "y", "vx", "vy"} {
+		if values[i], err = numberField(v, name, i >= 2); err != nil {
+			return Boid{}, err
+		}
+	}
+	return Boid{
+		ID:       int(id),
+		Position: Vector2{X: values[0], Y: values[1]},
+		Velocity: Vector2{X: values[2], Y: values[3]},
+		MaxSpeed: defaultMaxSpeed,
+		MaxForce: defaultMaxForce,
+		Cluster:  noCluster,
+		Flock:    noCluster,
+	}, nil
+}
+
+func boidsToJS(list []Boid) js.Value {
+	result := js.Global().Get("Array").New(len(list))
+	for i := range list {
+		result.SetIndex(i, boidToJS(&list[i]))
+	}
+	return result
+}
+
+// boidsFromJS reads an array of boids, rejecting the whole array if any element is malformed
+func boidsFromJS(v js.Value) ([]Boid, error) {
+	list := make([]Boid, v.Length())
+	for i := range list {
+		b, err := boidFromJS(v.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("boid at index %d: %w", i, err)
+		}
+		list[i] = b
+	}
+	return list, nil
+}
+
 func main() {
 	// Initialize default parameters
-	params = SimulationParams{
-		SeparationRadius:       25.0,
-		SeparationStrength:     1.5,
-		AlignmentRadius:        50.0,
-		AlignmentStrength:      1.0,
-		CohesionRadius:         50.0,
-		CohesionStrength:       1.0,
-		MouseAvoidanceDistance: 100.0,
-	}
+	params = DefaultSimulationParams()
 
 	// Register functions for JavaScript
 	js.Global().Set("initializeSimulation", js.FuncOf(initializeSimulation))
@@ -138,6 +920,52 @@ func main() {
 	js.Global().Set("updateCohesionParams", js.FuncOf(updateCohesionParams))
 	js.Global().Set("updateMouseAvoidanceDistance", js.FuncOf(updateMouseAvoidanceDistance))
 	js.Global().Set("getAllBoidData", js.FuncOf(getAllBoidData))
+	js.Global().Set("addBoids", js.FuncOf(addBoids))
+	js.Global().Set("removeBoids", js.FuncOf(removeBoids))
+	js.Global().Set("resizeWorld", js.FuncOf(resizeWorld))
+	js.Global().Set("setBoundaryMode", js.FuncOf(setBoundaryMode))
+	js.Global().Set("setSpatialIndex", js.FuncOf(setSpatialIndex))
+	js.Global().Set("setReorderInterval", js.FuncOf(setReorderInterval))
+	js.Global().Set("setPrecision", js.FuncOf(setPrecision))
+	js.Global().Set("getBoidIndex", js.FuncOf(getBoidIndex))
+	js.Global().Set("setParams", js.FuncOf(setParams))
+	js.Global().Set("setCamera", js.FuncOf(setCamera))
+	js.Global().Set("getCamera", js.FuncOf(getCamera))
+	js.Global().Set("panCamera", js.FuncOf(panCamera))
+	js.Global().Set("zoomCamera", js.FuncOf(zoomCamera))
+	js.Global().Set("getVisibleBoids", js.FuncOf(getVisibleBoids))
+	js.Global().Set("getBoidBuffers", js.FuncOf(getBoidBuffers))
+	js.Global().Set("getMetrics", js.FuncOf(getMetrics))
+	js.Global().Set("setHeatmap", js.FuncOf(setHeatmap))
+	js.Global().Set("getHeatmap", js.FuncOf(getHeatmap))
+	js.Global().Set("setTrailLength", js.FuncOf(setTrailLength))
+	js.Global().Set("getTrails", js.FuncOf(getTrails))
+	js.Global().Set("setForceDebug", js.FuncOf(setForceDebug))
+	js.Global().Set("getForceBreakdown", js.FuncOf(getForceBreakdown))
+	js.Global().Set("getForceBreakdowns", js.FuncOf(getForceBreakdowns))
+	js.Global().Set("getBoidNeighbors", js.FuncOf(getBoidNeighbors))
+	js.Global().Set("nearestBoid", js.FuncOf(nearestBoid))
+	js.Global().Set("boidsInCircle", js.FuncOf(boidsInCircle))
+	js.Global().Set("boidsInRect", js.FuncOf(boidsInRect))
+	js.Global().Set("raycast", js.FuncOf(raycast))
+	js.Global().Set("setStatsHistoryLength", js.FuncOf(setStatsHistoryLength))
+	js.Global().Set("getStatsHistory", js.FuncOf(getStatsHistory))
+	js.Global().Set("getClusters", js.FuncOf(getClusters))
+	js.Global().Set("setClusterOptions", js.FuncOf(setClusterOptions))
+	js.Global().Set("onFlockEvents", js.FuncOf(onFlockEvents))
+	js.Global().Set("pollFlockEvents", js.FuncOf(pollFlockEvents))
+	js.Global().Set("setProfiling", js.FuncOf(setProfiling))
+	js.Global().Set("getProfile", js.FuncOf(getProfile))
+	js.Global().Set("startTrace", js.FuncOf(startTrace))
+	js.Global().Set("stopTrace", js.FuncOf(stopTrace))
+	js.Global().Set("setDomain", js.FuncOf(setDomain))
+	js.Global().Set("clearDomain", js.FuncOf(clearDomain))
+	js.Global().Set("getHaloBoids", js.FuncOf(getHaloBoids))
+	js.Global().Set("getEmigrants", js.FuncOf(getEmigrants))
+	js.Global().Set("importBoids", js.FuncOf(importBoids))
+	js.Global().Set("importHalo", js.FuncOf(importHalo))
+	js.Global().Set("getParams", js.FuncOf(getParams))
+	js.Global().Set("getConfig", js.FuncOf(getConfig))
 
 	// Keep the program running
 	select {}
@@ -0,0 +1,187 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// runOptions configures a headless run
+type runOptions struct {
+	Boids        int
+	Steps        int
+	Width        float64
+	Height       float64
+	Workers      int
+	Seed         int64
+	Index        SpatialIndexKind
+	Domains      int
+	MetricsPath  string // CSV of flock metrics, empty to skip
+	MetricsEvery int    // steps between metrics rows
+	HeatmapPath  string // CSV of the accumulated heatmap, empty to skip
+	HeatmapCols  int
+	HeatmapRows  int
+	HeatmapDecay float64
+}
+
+// runOutput receives the flock after every step of a headless run
+type runOutput interface {
+	afterStep() error
+	Close() error
+}
+
+// The native build runs the simulation headless for experiments and benchmarks
+func main() {
+	var opts runOptions
+	var index string
+	flag.IntVar(&opts.Boids, "boids", 1000, "number of boids")
+	flag.IntVar(&opts.Steps, "steps", 1000, "number of simulation steps")
+	flag.Float64Var(&opts.Width, "width", 800.0, "world width")
+	flag.Float64Var(&opts.Height, "height", 600.0, "world height")
+	flag.IntVar(&opts.Workers, "workers", defaultForceWorkers, "goroutines computing forces")
+	flag.Int64Var(&opts.Seed, "seed", 1, "random seed for the initial flock")
+	flag.StringVar(&index, "index", string(IndexGrid), "spatial index: grid, flatgrid, hash, quadtree or kdtree")
+	flag.IntVar(&opts.Domains, "domains", 1, "vertical strips stepped by separate goroutines")
+	flag.StringVar(&opts.MetricsPath, "metrics", "", "write flock metrics as CSV to this file")
+	flag.IntVar(&opts.MetricsEvery, "metrics-every", 1, "steps between metrics rows")
+	flag.StringVar(&opts.HeatmapPath, "heatmap", "", "write the density and velocity heatmap as CSV to this file")
+	flag.IntVar(&opts.HeatmapCols, "heatmap-cols", 80, "heatmap columns")
+	flag.IntVar(&opts.HeatmapRows, "heatmap-rows", 60, "heatmap rows")
+	flag.Float64Var(&opts.HeatmapDecay, "heatmap-decay", 0, "fraction of the heatmap lost per step")
+	profileSteps := flag.Bool("profile", false, "print per-phase timings and neighbor counters")
+	tracePath := flag.String("trace", "", "write a Chrome trace of every step to this file")
+	flag.Parse()
+	opts.Index = SpatialIndexKind(index)
+
+	changeProfiling(*profileSteps)
+	if *tracePath != "" {
+		startTracing(0)
+	}
+
+	if err := runHeadless(opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if trace := stopTracing(); trace != nil {
+		if err := writeTrace(*tracePath, trace); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// writeTrace saves a trace as Trace Event Format JSON
+func writeTrace(path string, trace *Tracer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := trace.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runHeadless initializes a flock and steps it, reporting throughput
+func runHeadless(opts runOptions) (err error) {
+	if opts.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1, got %d", opts.Workers)
+	}
+
+	params = DefaultSimulationParams()
+	forceWorkers = opts.Workers
+	if err := changeSpatialIndex(opts.Index); err != nil {
+		return err
+	}
+
+	initSimulationFrom(rand.New(rand.NewSource(opts.Seed)), opts.Boids, opts.Width, opts.Height)
+
+	outputs, err := openRunOutputs(opts)
+	defer func() {
+		for _, out := range outputs {
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if opts.Domains > 1 {
+		// Partitions step concurrently, so outputs only see the final flock
+		result, err := runPartitioned(snapshotBoids(), opts.Domains, opts.Steps, interactionRadius())
+		if err != nil {
+			return err
+		}
+		loadBoids(result)
+		stepCount = opts.Steps
+		if err := notifyRunOutputs(outputs); err != nil {
+			return err
+		}
+	} else {
+		for i := 0; i < opts.Steps; i++ {
+			stepSimulation()
+			if err := notifyRunOutputs(outputs); err != nil {
+				return err
+			}
+		}
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("boids=%d steps=%d workers=%d index=%s domains=%d elapsed=%v steps/s=%.1f\n",
+		opts.Boids, opts.Steps, opts.Workers, opts.Index, opts.Domains, elapsed, float64(opts.Steps)/elapsed.Seconds())
+	if profiling {
+		printProfile(profile)
+	}
+	return nil
+}
+
+// openRunOutputs creates the files requested by opts
+func openRunOutputs(opts runOptions) ([]runOutput, error) {
+	var outputs []runOutput
+	if opts.MetricsPath != "" {
+		out, err := newMetricsCSV(opts.MetricsPath, opts.MetricsEvery)
+		if err != nil {
+			return outputs, err
+		}
+		outputs = append(outputs, out)
+	}
+	if opts.HeatmapPath != "" {
+		// Every partition would decay the shared grid once per frame
+		if opts.Domains > 1 {
+			return outputs, fmt.Errorf("heatmap output is not supported with more than one domain")
+		}
+		if err := configureHeatmap(opts.HeatmapCols, opts.HeatmapRows, opts.HeatmapDecay); err != nil {
+			return outputs, err
+		}
+		outputs = append(outputs, heatmapCSV{path: opts.HeatmapPath})
+	}
+	return outputs, nil
+}
+
+// notifyRunOutputs passes the current flock to every output
+func notifyRunOutputs(outputs []runOutput) error {
+	for _, out := range outputs {
+		if err := out.afterStep(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printProfile writes the accumulated counters, one phase per line
+func printProfile(p Profile) {
+	for phase := Phase(0); phase < phaseCount; phase++ {
+		fmt.Printf("  %-15s %v\n", phase, p.Durations[phase])
+	}
+	fmt.Printf("  candidates examined=%d accepted=%d\n", p.CandidatesExamined, p.CandidatesAccepted)
+	fmt.Printf("  allocations=%d bytes=%d\n", p.Allocations, p.AllocatedBytes)
+}
@@ -0,0 +1,109 @@
+package main
+
+import "math"
+
+// FlockMetrics are standard collective-motion measures of the flock
+type FlockMetrics struct {
+	Step           int
+	Count          int
+	Polarization   float64 // length of the mean heading, 1 when all boids fly the same way
+	Milling        float64 // normalized angular momentum about the centroid, 1 for a perfect mill
+	MeanNNDistance float64 // mean distance from each boid to its nearest neighbor
+	MinNNDistance  float64 // smallest nearest-neighbor distance in the flock
+	Centroid       Vector2
+	Bounds         Rect
+	SpeedMean      float64
+	SpeedVariance  float64
+}
+
+// computeFlockMetrics measures the current flock. Like the neighbor rules,
+// distances ignore wrapping across world edges.
+func computeFlockMetrics() FlockMetrics {
+	m := FlockMetrics{Step: stepCount, Count: len(boids)}
+	if len(boids) == 0 {
+		return m
+	}
+
+	ensureSpatialGrid()
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	var sumPosition, sumHeading Vector2
+	var sumSpeed, sumSpeedSquared float64
+	for i := range boids {
+		p, v := flock.Position(i), flock.Velocity(i)
+		sumPosition = sumPosition.Add(p)
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+
+		speed := v.Magnitude()
+		sumSpeed += speed
+		sumSpeedSquared += speed * speed
+		if speed > 0 {
+			sumHeading = sumHeading.Add(v.Div(speed))
+		}
+	}
+
+	n := float64(len(boids))
+	m.Centroid = sumPosition.Div(n)
+	m.Bounds = Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+	m.Polarization = sumHeading.Magnitude() / n
+	m.SpeedMean = sumSpeed / n
+	m.SpeedVariance = math.Max(sumSpeedSquared/n-m.SpeedMean*m.SpeedMean, 0)
+
+	// Angular momentum of unit headings about the centroid, with unit lever arms
+	angularMomentum := 0.0
+	for i := range boids {
+		r := flock.Position(i).Sub(m.Centroid)
+		v := flock.Velocity(i)
+		rLength, speed := r.Magnitude(), v.Magnitude()
+		if rLength > 0 && speed > 0 {
+			angularMomentum += (r.X*v.Y - r.Y*v.X) / (rLength * speed)
+		}
+	}
+	m.Milling = math.Abs(angularMomentum) / n
+
+	if len(boids) > 1 {
+		m.MeanNNDistance, m.MinNNDistance = nearestNeighborStats()
+	}
+	return m
+}
+
+// nearestNeighborStats returns the mean and minimum distance from each boid
+// to its nearest other boid
+func nearestNeighborStats() (mean, minDistance float64) {
+	minDistance = math.Inf(1)
+	sum := 0.0
+	for i := range boids {
+		// Ask for two so the boid itself can be skipped, even when it shares
+		// its position with a neighbor
+		for _, j := range spatialGrid.KNearest(flock.Position(i), 2) {
+			if j == i {
+				continue
+			}
+			d := flock.Position(i).Distance(flock.Position(j))
+			sum += d
+			minDistance = math.Min(minDistance, d)
+			break
+		}
+	}
+	return sum / float64(len(boids)), minDistance
+}
+
+// metricsToMap converts metrics to a JS-friendly map
+func metricsToMap(m FlockMetrics) map[string]interface{} {
+	return map[string]interface{}{
+		"step":           m.Step,
+		"count":          m.Count,
+		"polarization":   m.Polarization,
+		"milling":        m.Milling,
+		"meanNNDistance": m.MeanNNDistance,
+		"minNNDistance":  m.MinNNDistance,
+		"centroid":       map[string]interface{}{"x": m.Centroid.X, "y": m.Centroid.Y},
+		"bounds": map[string]interface{}{
+			"x": m.Bounds.X, "y": m.Bounds.Y, "width": m.Bounds.Width, "height": m.Bounds.Height,
+		},
+		"speedMean":     m.SpeedMean,
+		"speedVariance": m.SpeedVariance,
+	}
+}
@@ -0,0 +1,55 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// metricsCSV writes flock metrics to a CSV file during a headless run
+type metricsCSV struct {
+	file  *os.File
+	w     *bufio.Writer
+	every int
+}
+
+// newMetricsCSV creates path and writes the header row
+func newMetricsCSV(path string, every int) (*metricsCSV, error) {
+	if every < 1 {
+		return nil, fmt.Errorf("metrics interval must be at least 1, got %d", every)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	out := &metricsCSV{file: f, w: bufio.NewWriter(f), every: every}
+	fmt.Fprintln(out.w, "step,count,polarization,milling,meanNNDistance,minNNDistance,"+
+		"centroidX,centroidY,minX,minY,maxX,maxY,speedMean,speedVariance")
+	return out, nil
+}
+
+// afterStep appends a row every few steps
+func (out *metricsCSV) afterStep() error {
+	if stepCount%out.every != 0 {
+		return nil
+	}
+
+	m := computeFlockMetrics()
+	_, err := fmt.Fprintf(out.w, "%d,%d,%g,%g,%g,%g,%g,%g,%g,%g,%g,%g,%g,%g\n",
+		m.Step, m.Count, m.Polarization, m.Milling, m.MeanNNDistance, m.MinNNDistance,
+		m.Centroid.X, m.Centroid.Y, m.Bounds.X, m.Bounds.Y,
+		m.Bounds.X+m.Bounds.Width, m.Bounds.Y+m.Bounds.Height, m.SpeedMean, m.SpeedVariance)
+	return err
+}
+
+// Close flushes buffered rows and closes the file
+func (out *metricsCSV) Close() error {
+	if err := out.w.Flush(); err != nil {
+		out.file.Close()
+		return err
+	}
+	return out.file.Close()
+}
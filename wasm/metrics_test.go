@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestFlockMetricsPolarizedLine(t *testing.T) {
+	params = DefaultSimulationParams()
+	loadBoids([]Boid{
+		{ID: 0, Position: Vector2{X: 100, Y: 100}, Velocity: Vector2{X: 1, Y: 0}},
+		{ID: 1, Position: Vector2{X: 110, Y: 100}, Velocity: Vector2{X: 2, Y: 0}},
+		{ID: 2, Position: Vector2{X: 130, Y: 100}, Velocity: Vector2{X: 3, Y: 0}},
+	})
+
+	m := computeFlockMetrics()
+
+	if !almostEqual(m.Polarization, 1) {
+		t.Errorf("Polarization = %v, want 1", m.Polarization)
+	}
+	if !almostEqual(m.Milling, 0) {
+		t.Errorf("Milling = %v, want 0", m.Milling)
+	}
+	// Nearest neighbors: 10, 10, 20
+	if !almostEqual(m.MeanNNDistance, 40.0/3) || !almostEqual(m.MinNNDistance, 10) {
+		t.Errorf("NN distance mean %v min %v, want %v and 10", m.MeanNNDistance, m.MinNNDistance, 40.0/3)
+	}
+	if m.Centroid != (Vector2{X: 340.0 / 3, Y: 100}) {
+		t.Errorf("Centroid = %v, want (113.33, 100)", m.Centroid)
+	}
+	if m.Bounds != (Rect{X: 100, Y: 100, Width: 30, Height: 0}) {
+		t.Errorf("Bounds = %+v, want 100,100 30x0", m.Bounds)
+	}
+	if !almostEqual(m.SpeedMean, 2) || !almostEqual(m.SpeedVariance, 2.0/3) {
+		t.Errorf("speed mean %v variance %v, want 2 and 0.667", m.SpeedMean, m.SpeedVariance)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestFlockMetricsMill(t *testing.T) {
+	params = DefaultSimulationParams()
+	loadBoids(nil)
+	for i := 0; i < 36; i++ {
+		angle := float64(i) * 2 * math.Pi / 36
+		// Counter-clockwise tangential velocity around (400, 300)
+		appendBoid(Boid{
+			ID:       i,
+			Position: Vector2{X: 400 + 100*math.Cos(angle), Y: 300 + 100*math.Sin(angle)},
+			Velocity: Vector2{X: -math.Sin(angle), Y: math.Cos(angle)},
+		})
+	}
+
+	m := computeFlockMetrics()
+
+	if !almostEqual(m.Milling, 1) {
+		t.Errorf("Milling = %v, want 1", m.Milling)
+	}
+	if m.Polarization > 1e-9 {
+		t.Errorf("Polarization = %v, want 0", m.Polarization)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestNearestNeighborStatsMatchBruteForce(t *testing.T) {
+	params = DefaultSimulationParams()
+	rng := rand.New(rand.NewSource(29))
+	for _, kind := range []SpatialIndexKind{IndexGrid, IndexHash, IndexQuadtree, IndexKDTree, IndexFlatGrid} {
+		spatialIndexKind = kind
+		initSimulationFrom(rng, 300, 800.0, 600.0)
+		m := computeFlockMetrics()
+
+		sum, minDistance := 0.0, math.Inf(1)
+		for i := range boids {
+			nearest := math.Inf(1)
+			for j := range boids {
+				if i != j {
+					nearest = math.Min(nearest, flock.Position(i).Distance(flock.Position(j)))
+				}
+			}
+			sum += nearest
+			minDistance = math.Min(minDistance, nearest)
+		}
+
+		if !almostEqual(m.MeanNNDistance, sum/300) || !almostEqual(m.MinNNDistance, minDistance) {
+			t.Errorf("%s: NN mean %v min %v, want %v and %v", kind, m.MeanNNDistance, m.MinNNDistance, sum/300, minDistance)
+		}
+	}
+
+	// Reset global state
+	spatialIndexKind = IndexGrid
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestFlockMetricsEmpty(t *testing.T) {
+	loadBoids(nil)
+	if m := computeFlockMetrics(); m.Count != 0 || m.Polarization != 0 {
+		t.Errorf("computeFlockMetrics() on empty flock = %+v, want zero", m)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// defaultMortonReorderInterval is how many steps pass between Z-order reorders
+const defaultMortonReorderInterval = 60
+
+// mortonCode interleaves the bits of two 16-bit cell coordinates into a Z-order key
+func mortonCode(x, y uint32) uint32 {
+	return spreadBits(x) | spreadBits(y)<<1
+}
+
+// spreadBits inserts a zero bit between each of the low 16 bits of v
+func spreadBits(v uint32) uint32 {
+	v &= 0x0000ffff
+	v = (v | v<<8) & 0x00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f
+	v = (v | v<<2) & 0x33333333
+	v = (v | v<<1) & 0x55555555
+	return v
+}
+
+// reorderBoidsMorton sorts boids along a Z-order curve over the spatial grid
+// cells, so boids that are neighbors in space are also close in memory.
+// Boids before and after forceCursor are sorted separately: a budgeted
+// rotation then still reaches every boid it had not recomputed yet before
+// it returns to those it already did.
+func reorderBoidsMorton() {
+	if len(boids) < 2 {
+		return
+	}
+
+	// Measure cells from the flock's corner so open-sky coordinates work too
+	minX, minY := math.Inf(1), math.Inf(1)
+	for i := range boids {
+		p := flock.Position(i)
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+	}
+
+	codes := make([]uint32, len(boids))
+	for i := range boids {
+		p := flock.Position(i)
+		col := math.Min((p.X-minX)/spatialCellSize, 0xffff)
+		row := math.Min((p.Y-minY)/spatialCellSize, 0xffff)
+		codes[i] = mortonCode(uint32(col), uint32(row))
+	}
+
+	order := make([]int, len(boids))
+	for i := range order {
+		order[i] = i
+	}
+	split := forceCursor % len(boids)
+	sort.SliceStable(order, func(a, b int) bool {
+		pendingA, pendingB := order[a] >= split, order[b] >= split
+		if pendingA != pendingB {
+			return !pendingA
+		}
+		return codes[order[a]] < codes[order[b]]
+	})
+
+	applyBoidPermutation(order)
+	forceCursor = split
+}
+
+// applyBoidPermutation reorders boids so that the boid previously at order[i]
+// ends up at index i, keeping the ID lookup in step
+func applyBoidPermutation(order []int) {
+	reordered := make([]boidAttrs, len(boids))
+	for i, from := range order {
+		reordered[i] = boids[from]
+	}
+	boids = reordered
+	flock.permute(order)
+	reindexBoids()
+	spatialGridStale = true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMortonCode(t *testing.T) {
+	tests := []struct {
+		x, y     uint32
+		expected uint32
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{0, 1, 2},
+		{1, 1, 3},
+		{2, 0, 4},
+		{3, 3, 15},
+		{0xffff, 0xffff, 0xffffffff},
+	}
+
+	for _, tt := range tests {
+		if got := mortonCode(tt.x, tt.y); got != tt.expected {
+			t.Errorf("mortonCode(%d, %d) = %d, want %d", tt.x, tt.y, got, tt.expected)
+		}
+	}
+}
+
+func TestReorderBoidsMorton(t *testing.T) {
+	spatialCellSize = 100.0
+	forceCursor = 0
+	loadBoids([]Boid{
+		NewBoid(350.0, 350.0), // cell (3, 3)
+		NewBoid(50.0, 50.0),   // cell (0, 0)
+		NewBoid(150.0, 50.0),  // cell (1, 0)
+		NewBoid(50.0, 150.0),  // cell (0, 1)
+	})
+	ids := []int{boids[1].ID, boids[2].ID, boids[3].ID, boids[0].ID}
+	reindexBoids()
+
+	reorderBoidsMorton()
+
+	for i, id := range ids {
+		if boids[i].ID != id {
+			t.Errorf("boid %d id = %d, want %d", i, boids[i].ID, id)
+		}
+		if index, ok := lookupBoidIndex(id); !ok || index != i {
+			t.Errorf("lookupBoidIndex(%d) = (%d, %v), want (%d, true)", id, index, ok, i)
+		}
+	}
+
+	// Reset global state
+	spatialCellSize = defaultCellSize
+	loadBoids(nil)
+	boidIndex = nil
+}
+
+func TestReorderBoidsMortonKeepsForceRotation(t *testing.T) {
+	spatialCellSize = 100.0
+	loadBoids([]Boid{
+		NewBoid(350.0, 350.0),
+		NewBoid(50.0, 50.0),
+		NewBoid(250.0, 250.0), // forces not yet recomputed in this rotation from here on
+		NewBoid(150.0, 50.0),
+		NewBoid(50.0, 150.0),
+	})
+	pending := map[int]bool{boids[2].ID: true, boids[3].ID: true, boids[4].ID: true}
+	reindexBoids()
+	forceCursor = 2
+
+	reorderBoidsMorton()
+
+	if forceCursor != 2 {
+		t.Fatalf("forceCursor = %d, want 2", forceCursor)
+	}
+	for i := range boids {
+		if pending[boids[i].ID] != (i >= forceCursor) {
+			t.Errorf("boid %d (id %d) moved across the rotation cursor", i, boids[i].ID)
+		}
+	}
+	if flock.Position(0).X != 50.0 || flock.Position(2).X != 150.0 {
+		t.Errorf("each side of the cursor should be in Z-order, got %+v", boids)
+	}
+
+	// Reset global state
+	spatialCellSize = defaultCellSize
+	forceCursor = 0
+	loadBoids(nil)
+	boidIndex = nil
+}
+
+func TestStepSimulationReordersPeriodically(t *testing.T) {
+	initSimulation(200, 800.0, 600.0)
+	mortonReorderInterval = 5
+	stepCount = 0
+
+	for i := 0; i < 5; i++ {
+		stepSimulation()
+	}
+	if stepCount != 5 {
+		t.Errorf("stepCount = %d, want 5", stepCount)
+	}
+
+	// Every boid must still be found through its ID after reorders
+	for i := range boids {
+		if index, ok := lookupBoidIndex(boids[i].ID); !ok || index != i {
+			t.Fatalf("lookupBoidIndex(%d) = (%d, %v), want (%d, true)", boids[i].ID, index, ok, i)
+		}
+	}
+
+	// Reset global state
+	mortonReorderInterval = defaultMortonReorderInterval
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+}
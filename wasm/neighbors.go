@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// RuleNeighbor is a boid that a steering rule takes into account
+type RuleNeighbor struct {
+	ID       int
+	Distance float64
+}
+
+// ruleRadius returns the neighborhood radius of a rule
+func ruleRadius(rule neighborRule) float64 {
+	switch rule {
+	case ruleSeparation:
+		return params.SeparationRadius
+	case ruleAlignment:
+		return params.AlignmentRadius
+	default:
+		return params.CohesionRadius
+	}
+}
+
+// inspectNeighbors lists, per rule, the boids the next step will use for the
+// boid at index, nearest first. It runs the same index query and distance
+// test as the rules, so the lists match what they accept. Rules have no field
+// of view; every boid inside the radius counts.
+func inspectNeighbors(index int) [ruleCount][]RuleNeighbor {
+	ensureSpatialGrid()
+
+	var result [ruleCount][]RuleNeighbor
+	position := flock.Position(index)
+	for rule := neighborRule(0); rule < ruleCount; rule++ {
+		radius := ruleRadius(rule)
+		neighbors := []RuleNeighbor{}
+		for _, other := range spatialGrid.GetNeighbors(position, radius) {
+			if other == index {
+				continue
+			}
+			distanceSquared := position.DistanceSquared(flock.Position(other))
+			if withinRuleRadius(distanceSquared, radius*radius) {
+				neighbors = append(neighbors, RuleNeighbor{ID: boids[other].ID, Distance: math.Sqrt(distanceSquared)})
+			}
+		}
+		sort.Slice(neighbors, func(a, b int) bool {
+			if neighbors[a].Distance != neighbors[b].Distance {
+				return neighbors[a].Distance < neighbors[b].Distance
+			}
+			return neighbors[a].ID < neighbors[b].ID
+		})
+		result[rule] = neighbors
+	}
+	return result
+}
+
+// neighborsToMap converts inspectNeighbors output to a JS-friendly map
+func neighborsToMap(id int, lists [ruleCount][]RuleNeighbor) map[string]interface{} {
+	toJS := func(rule neighborRule) map[string]interface{} {
+		ids := make([]interface{}, len(lists[rule]))
+		distances := make([]interface{}, len(lists[rule]))
+		for i, n := range lists[rule] {
+			ids[i] = n.ID
+			distances[i] = n.Distance
+		}
+		return map[string]interface{}{
+			"radius":    ruleRadius(rule),
+			"ids":       ids,
+			"distances": distances,
+		}
+	}
+
+	return map[string]interface{}{
+		"id":         id,
+		"separation": toJS(ruleSeparation),
+		"alignment":  toJS(ruleAlignment),
+		"cohesion":   toJS(ruleCohesion),
+		// Cohesion uses a center-of-mass approximation instead of this list
+		"cohesionApproximated": barnesHutActive(),
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestInspectNeighborsMatchesRules(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(11)), 800, 400.0, 300.0)
+	stepSimulation()
+
+	for i := range boids {
+		lists := inspectNeighbors(i)
+		b := boidAt(i)
+		fb := b.forceBreakdown(i, &neighborStats{})
+		for rule := range lists {
+			if len(lists[rule]) != fb.Neighbors[rule] {
+				t.Fatalf("boid %d rule %d: inspected %d neighbors, rule accepted %d", i, rule, len(lists[rule]), fb.Neighbors[rule])
+			}
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	stepCount = 0
+}
+
+func TestInspectNeighborsSortsByDistance(t *testing.T) {
+	params = DefaultSimulationParams()
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{
+		{ID: 10, Position: Vector2{X: 100, Y: 100}},
+		{ID: 11, Position: Vector2{X: 140, Y: 100}},
+		{ID: 12, Position: Vector2{X: 100, Y: 90}},
+		{ID: 13, Position: Vector2{X: 100, Y: 100}}, // coincident, skipped like the rules do
+		{ID: 14, Position: Vector2{X: 300, Y: 300}},
+	})
+	reindexBoids()
+
+	lists := inspectNeighbors(0)
+	if got := lists[ruleSeparation]; len(got) != 1 || got[0] != (RuleNeighbor{ID: 12, Distance: 10}) {
+		t.Errorf("separation = %v, want [{12 10}]", got)
+	}
+	want := []RuleNeighbor{{ID: 12, Distance: 10}, {ID: 11, Distance: 40}}
+	for _, rule := range []neighborRule{ruleAlignment, ruleCohesion} {
+		got := lists[rule]
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("rule %d = %v, want %v", rule, got, want)
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
@@ -0,0 +1,11 @@
+//go:build js && wasm
+
+package main
+
+// defaultForceWorkers is 1: the js/wasm runtime has a single thread
+var defaultForceWorkers = 1
+
+// parallelFor runs fn serially; goroutines would only interleave on one thread
+func parallelFor(n, workers int, fn func(start, end int)) {
+	fn(0, n)
+}
@@ -0,0 +1,38 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultForceWorkers uses every available core in native builds
+var defaultForceWorkers = runtime.GOMAXPROCS(0)
+
+// parallelFor runs fn over [0, n) in chunks spread across workers goroutines.
+// Chunks are claimed dynamically so clustered flocks still balance.
+func parallelFor(n, workers int, fn func(start, end int)) {
+	if workers <= 1 || n <= parallelChunkSize {
+		fn(0, n)
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				start := int(next.Add(parallelChunkSize)) - parallelChunkSize
+				if start >= n {
+					return
+				}
+				fn(start, min(start+parallelChunkSize, n))
+			}
+		}()
+	}
+	wg.Wait()
+}
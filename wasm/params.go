@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ParamSpec describes a tunable simulation parameter and its allowed range
+type ParamSpec struct {
+	Name    string
+	Default float64
+	Min     float64
+	Max     float64
+	Step    float64
+	field   func(p *SimulationParams) *float64
+}
+
+// Engine configuration defaults
+const (
+	defaultCellSize = 75.0 // Slightly larger than max interaction radius
+	defaultMaxSpeed = 2.0
+	defaultMaxForce = 0.03
+)
+
+// paramSpecs lists every parameter exposed to JavaScript, keyed by its JS name
+var paramSpecs = []ParamSpec{
+	{Name: "separationRadius", Default: 25.0, Min: 10.0, Max: 100.0, Step: 5.0,
+		field: func(p *SimulationParams) *float64 { return &p.SeparationRadius }},
+	{Name: "separationStrength", Default: 1.5, Min: 0.0, Max: 3.0, Step: 0.1,
+		field: func(p *SimulationParams) *float64 { return &p.SeparationStrength }},
+	{Name: "alignmentRadius", Default: 50.0, Min: 20.0, Max: 150.0, Step: 10.0,
+		field: func(p *SimulationParams) *float64 { return &p.AlignmentRadius }},
+	{Name: "alignmentStrength", Default: 1.0, Min: 0.0, Max: 3.0, Step: 0.1,
+		field: func(p *SimulationParams) *float64 { return &p.AlignmentStrength }},
+	{Name: "cohesionRadius", Default: 50.0, Min: 20.0, Max: 400.0, Step: 10.0, // reaches barnesHutRadius
+		field: func(p *SimulationParams) *float64 { return &p.CohesionRadius }},
+	{Name: "cohesionStrength", Default: 1.0, Min: 0.0, Max: 3.0, Step: 0.1,
+		field: func(p *SimulationParams) *float64 { return &p.CohesionStrength }},
+	{Name: "mouseAvoidanceDistance", Default: 100.0, Min: 50.0, Max: 200.0, Step: 10.0,
+		field: func(p *SimulationParams) *float64 { return &p.MouseAvoidanceDistance }},
+	{Name: "barnesHutTheta", Default: 0.5, Min: 0.0, Max: 1.5, Step: 0.05,
+		field: func(p *SimulationParams) *float64 { return &p.BarnesHutTheta }},
+	{Name: "barnesHutRadius", Default: 300.0, Min: 0.0, Max: 2000.0, Step: 50.0,
+		field: func(p *SimulationParams) *float64 { return &p.BarnesHutRadius }},
+}
+
+// DefaultSimulationParams returns the parameters the engine starts with
+func DefaultSimulationParams() SimulationParams {
+	var p SimulationParams
+	for _, spec := range paramSpecs {
+		*spec.field(&p) = spec.Default
+	}
+	return p
+}
+
+// findParamSpec looks up a parameter by its JS name
+func findParamSpec(name string) (ParamSpec, bool) {
+	for _, spec := range paramSpecs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return ParamSpec{}, false
+}
+
+// applyParams updates the named parameters and returns the resulting set.
+// Every key is validated against its spec's range before any value is
+// written, so a rejected update leaves params untouched.
+func applyParams(updates map[string]float64) (SimulationParams, error) {
+	next := params
+	for name, value := range updates {
+		spec, ok := findParamSpec(name)
+		if !ok {
+			return params, fmt.Errorf("unknown parameter %q", name)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return params, fmt.Errorf("parameter %q must be a finite number", name)
+		}
+		if value < spec.Min || value > spec.Max {
+			return params, fmt.Errorf("parameter %q must be between %v and %v, got %v", name, spec.Min, spec.Max, value)
+		}
+		*spec.field(&next) = value
+	}
+
+	params = next
+	return params, nil
+}
+
+// paramsToMap converts parameters to a JS-friendly map keyed by parameter name
+func paramsToMap(p SimulationParams) map[string]interface{} {
+	result := make(map[string]interface{}, len(paramSpecs))
+	for _, spec := range paramSpecs {
+		result[spec.Name] = *spec.field(&p)
+	}
+	return result
+}
+
+// engineConfig returns the live engine configuration together with parameter ranges
+func engineConfig() map[string]interface{} {
+	ranges := make(map[string]interface{}, len(paramSpecs))
+	for _, spec := range paramSpecs {
+		ranges[spec.Name] = map[string]interface{}{
+			"default": spec.Default,
+			"min":     spec.Min,
+			"max":     spec.Max,
+			"step":    spec.Step,
+		}
+	}
+
+	return map[string]interface{}{
+		"worldWidth":      worldWidth,
+		"worldHeight":     worldHeight,
+		"canvasWidth":     camera.Width,
+		"canvasHeight":    camera.Height,
+		"boundaryMode":    string(boundaryMode),
+		"cellSize":        spatialCellSize,
+		"spatialIndex":    string(spatialIndexKind),
+		"reorderInterval": mortonReorderInterval,
+		"precision":       string(precision),
+		"workers":         forceWorkers,
+		"clusterRadius":   clusterOptions.Radius,
+		"clusterMinSize":  clusterOptions.MinSize,
+		"clusterInterval": clusterOptions.Interval,
+		"statsHistory":    len(statsHistory.records),
+		"trailLength":     trailLength(),
+		"forceDebug":      forceDebug,
+		"boidCount":       len(boids),
+		"maxSpeed":        defaultMaxSpeed,
+		"maxForce":        defaultMaxForce,
+		"params":          ranges,
+	}
+}
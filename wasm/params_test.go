@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDefaultSimulationParams(t *testing.T) {
+	p := DefaultSimulationParams()
+
+	expected := SimulationParams{
+		SeparationRadius:       25.0,
+		SeparationStrength:     1.5,
+		AlignmentRadius:        50.0,
+		AlignmentStrength:      1.0,
+		CohesionRadius:         50.0,
+		CohesionStrength:       1.0,
+		MouseAvoidanceDistance: 100.0,
+		BarnesHutTheta:         0.5,
+		BarnesHutRadius:        300.0,
+	}
+	if p != expected {
+		t.Errorf("DefaultSimulationParams() = %+v, want %+v", p, expected)
+	}
+}
+
+func TestParamSpecsWithinRange(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, spec := range paramSpecs {
+		if seen[spec.Name] {
+			t.Errorf("duplicate parameter name %q", spec.Name)
+		}
+		seen[spec.Name] = true
+
+		if spec.Default < spec.Min || spec.Default > spec.Max {
+			t.Errorf("%s default %v outside range [%v, %v]", spec.Name, spec.Default, spec.Min, spec.Max)
+		}
+	}
+}
+
+func TestParamsToMap(t *testing.T) {
+	p := DefaultSimulationParams()
+	p.CohesionRadius = 80.0
+
+	m := paramsToMap(p)
+	if len(m) != len(paramSpecs) {
+		t.Errorf("paramsToMap() has %d entries, want %d", len(m), len(paramSpecs))
+	}
+	if m["cohesionRadius"] != 80.0 {
+		t.Errorf("cohesionRadius = %v, want 80.0", m["cohesionRadius"])
+	}
+	if m["separationStrength"] != 1.5 {
+		t.Errorf("separationStrength = %v, want 1.5", m["separationStrength"])
+	}
+}
+
+func TestEngineConfig(t *testing.T) {
+	spatialCellSize = 50.0
+	worldWidth, worldHeight = 400.0, 300.0
+
+	config := engineConfig()
+	if config["cellSize"] != 50.0 {
+		t.Errorf("cellSize = %v, want 50.0", config["cellSize"])
+	}
+	if config["worldWidth"] != 400.0 || config["worldHeight"] != 300.0 {
+		t.Errorf("world size = (%v, %v), want (400, 300)", config["worldWidth"], config["worldHeight"])
+	}
+	ranges, ok := config["params"].(map[string]interface{})
+	if !ok || len(ranges) != len(paramSpecs) {
+		t.Fatalf("params ranges = %v, want %d entries", config["params"], len(paramSpecs))
+	}
+
+	// Reset global state
+	spatialCellSize = defaultCellSize
+	worldWidth, worldHeight = 800.0, 600.0
+}
+
+func TestApplyParams(t *testing.T) {
+	params = DefaultSimulationParams()
+
+	result, err := applyParams(map[string]float64{
+		"separationRadius": 40.0,
+		"cohesionStrength": 2.5,
+	})
+	if err != nil {
+		t.Fatalf("applyParams() error = %v", err)
+	}
+	if result.SeparationRadius != 40.0 || result.CohesionStrength != 2.5 {
+		t.Errorf("applyParams() = %+v, want separationRadius 40 and cohesionStrength 2.5", result)
+	}
+	if params != result {
+		t.Errorf("params = %+v, want %+v", params, result)
+	}
+
+	// Untouched fields keep their values
+	if result.AlignmentRadius != 50.0 {
+		t.Errorf("AlignmentRadius = %v, want 50.0", result.AlignmentRadius)
+	}
+
+	// Reset global state
+	params = SimulationParams{}
+}
+
+func TestApplyParamsRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates map[string]float64
+	}{
+		{
+			name:    "unknown key",
+			updates: map[string]float64{"separationRadius": 40.0, "turnRate": 1.0},
+		},
+		{
+			name:    "not finite",
+			updates: map[string]float64{"alignmentRadius": math.Inf(1)},
+		},
+		{
+			name:    "negative radius",
+			updates: map[string]float64{"separationRadius": -10.0},
+		},
+		{
+			name:    "negative strength",
+			updates: map[string]float64{"cohesionStrength": -1.0},
+		},
+		{
+			name:    "negative theta",
+			updates: map[string]float64{"barnesHutTheta": -0.5},
+		},
+		{
+			name:    "above range",
+			updates: map[string]float64{"alignmentRadius": 40.0, "mouseAvoidanceDistance": 1000.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params = DefaultSimulationParams()
+
+			if _, err := applyParams(tt.updates); err == nil {
+				t.Errorf("applyParams(%v) error = nil, want error", tt.updates)
+			}
+
+			// Rejected updates must not be partially applied
+			if params != DefaultSimulationParams() {
+				t.Errorf("params = %+v, want defaults after rejected update", params)
+			}
+		})
+	}
+
+	// Reset global state
+	params = SimulationParams{}
+}
+
+func TestBarnesHutReachableWithinRanges(t *testing.T) {
+	cohesion, _ := findParamSpec("cohesionRadius")
+	barnesHut, _ := findParamSpec("barnesHutRadius")
+	if cohesion.Max < barnesHut.Default {
+		t.Errorf("cohesionRadius max %v is below the Barnes–Hut switch-over %v", cohesion.Max, barnesHut.Default)
+	}
+}
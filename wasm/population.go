@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// SpawnMode selects where new boids are placed
+type SpawnMode string
+
+const (
+	SpawnRandom SpawnMode = "random" // anywhere in the world
+	SpawnPoint  SpawnMode = "point"  // exactly at (X, Y)
+	SpawnRect   SpawnMode = "rect"   // inside the rectangle at (X, Y) with Width x Height
+)
+
+// SpawnSpec describes how newly added boids are placed and launched
+type SpawnSpec struct {
+	Mode     SpawnMode
+	X, Y     float64
+	Width    float64
+	Height   float64
+	Velocity *Vector2 // nil draws a random initial velocity, as NewBoid does
+}
+
+// spawnRand supplies spawn positions and velocities when no seeded source is
+// given. math/rand's global source can no longer be seeded, so reproducible
+// runs pass their own to initSimulationFrom.
+var spawnRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// Validate checks that the spec can be used to spawn boids
+func (s SpawnSpec) Validate() error {
+	switch s.Mode {
+	case SpawnRandom, SpawnPoint:
+		return nil
+	case SpawnRect:
+		if s.Width < 0 || s.Height < 0 {
+			return fmt.Errorf("spawn rect size must not be negative")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown spawn mode %q", s.Mode)
+	}
+}
+
+// spawnBoid creates a single boid according to the spec, drawing from rng
+func (s SpawnSpec) spawnBoid(rng *rand.Rand) Boid {
+	var x, y float64
+	switch s.Mode {
+	case SpawnPoint:
+		x, y = s.X, s.Y
+	case SpawnRect:
+		x = s.X + rng.Float64()*s.Width
+		y = s.Y + rng.Float64()*s.Height
+	default:
+		x = rng.Float64() * worldWidth
+		y = rng.Float64() * worldHeight
+	}
+
+	boid := NewBoid(x, y)
+	boid.Velocity = Vector2{X: (rng.Float64() - 0.5) * 2.0, Y: (rng.Float64() - 0.5) * 2.0}
+	if s.Velocity != nil {
+		boid.Velocity = *s.Velocity
+	}
+	return boid
+}
+
+// initSimulation replaces the flock with count randomly placed boids
+func initSimulation(count int, width, height float64) {
+	initSimulationFrom(spawnRand, count, width, height)
+}
+
+// initSimulationFrom is initSimulation with the flock drawn from rng, so the
+// same seed always gives the same flock
+func initSimulationFrom(rng *rand.Rand, count int, width, height float64) {
+	worldWidth = width
+	worldHeight = height
+	boids = make([]boidAttrs, 0, count)
+	flock = newBoidArrays(precision)
+
+	// Initialize spatial grid with optimal cell size
+	spatialGrid = newSpatialIndex()
+
+	spawn := SpawnSpec{Mode: SpawnRandom}
+	for i := 0; i < count; i++ {
+		appendBoid(spawn.spawnBoid(rng))
+	}
+	reindexBoids()
+	resetFlockTracking()
+}
+
+// appendBoids appends n boids to the existing flock without touching the others
+// and returns the IDs of the new boids
+func appendBoids(n int, spec SpawnSpec) ([]int, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("boid count must not be negative")
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, n)
+	for i := 0; i < n; i++ {
+		boid := spec.spawnBoid(spawnRand)
+		ids[i] = boid.ID
+		appendBoid(boid)
+	}
+	reindexBoids()
+	rebuildSpatialGrid()
+	return ids, nil
+}
+
+// trimBoids drops the n most recently added boids. IDs grow with every boid
+// added, so those are the n highest IDs wherever reordering has moved them.
+func trimBoids(n int) error {
+	if n < 0 {
+		return fmt.Errorf("boid count must not be negative")
+	}
+	if n > len(boids) {
+		n = len(boids)
+	}
+
+	newest := make([]int, len(boids))
+	for i := range newest {
+		newest[i] = i
+	}
+	sort.Slice(newest, func(a, b int) bool {
+		return boids[newest[a]].ID > boids[newest[b]].ID
+	})
+	return deleteBoidsAt(newest[:n])
+}
+
+// deleteBoidsAt drops the boids at the given indices, keeping the rest in order
+func deleteBoidsAt(indices []int) error {
+	remove := make(map[int]bool, len(indices))
+	for _, index := range indices {
+		if index < 0 || index >= len(boids) {
+			return fmt.Errorf("boid index %d out of range", index)
+		}
+		remove[index] = true
+	}
+
+	filterBoids(func(i int) bool { return !remove[i] })
+	reindexBoids()
+	rebuildSpatialGrid()
+	return nil
+}
+
+// deleteBoidsByID drops the boids with the given IDs, keeping the rest in order
+func deleteBoidsByID(ids []int) error {
+	indices := make([]int, len(ids))
+	for i, id := range ids {
+		index, ok := lookupBoidIndex(id)
+		if !ok {
+			return fmt.Errorf("unknown boid id %d", id)
+		}
+		indices[i] = index
+	}
+	return deleteBoidsAt(indices)
+}
+
+// lookupBoidIndex returns the current index of the boid with the given ID
+func lookupBoidIndex(id int) (int, bool) {
+	index, ok := boidIndex[id]
+	if !ok || index >= len(boids) || boids[index].ID != id {
+		return 0, false
+	}
+	return index, true
+}
+
+// reindexBoids rebuilds the ID to index lookup from the boids slice
+func reindexBoids() {
+	boidIndex = make(map[int]int, len(boids))
+	for i := range boids {
+		boidIndex[boids[i].ID] = i
+	}
+}
+
+// ensureSpatialGrid rebuilds the spatial index if boids changed since it was
+// built. Queries outside the step call it before reading the index.
+func ensureSpatialGrid() {
+	if spatialGrid == nil || spatialGridStale {
+		rebuildSpatialGrid()
+	}
+}
+
+// rebuildSpatialGrid re-inserts every boid so grid indices match the boids slice
+func rebuildSpatialGrid() {
+	if spatialGrid == nil {
+		spatialGrid = newSpatialIndex()
+	}
+	spatialGridStale = false
+
+	tracer.begin("grid.Clear")
+	spatialGrid.Clear()
+	tracer.end("grid.Clear")
+	tracer.begin("grid.Insert")
+	for i := range boids {
+		spatialGrid.Insert(i, flock.Position(i))
+	}
+	tracer.end("grid.Insert")
+	tracer.begin("grid.Build")
+	spatialGrid.Build()
+	rebuildCohesionTree()
+	tracer.end("grid.Build")
+}
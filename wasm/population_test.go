@@ -0,0 +1,226 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAppendBoidsKeepsExistingFlock(t *testing.T) {
+	initSimulation(5, 800.0, 600.0)
+	before := snapshotBoids()
+
+	ids, err := appendBoids(3, SpawnSpec{Mode: SpawnRandom})
+	if err != nil {
+		t.Fatalf("appendBoids() error = %v", err)
+	}
+
+	if len(boids) != 8 {
+		t.Fatalf("boid count = %d, want 8", len(boids))
+	}
+	for i, id := range ids {
+		if boids[5+i].ID != id {
+			t.Errorf("returned id %d does not match boid %d id %d", id, 5+i, boids[5+i].ID)
+		}
+	}
+	for i := range before {
+		if boidAt(i) != before[i] {
+			t.Errorf("boid %d changed from %v to %v", i, before[i], boidAt(i))
+		}
+	}
+
+	// Grid must know about the new boids immediately
+	found := false
+	for _, index := range spatialGrid.GetNeighbors(flock.Position(7), 1.0) {
+		if index == 7 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("new boid 7 not found in spatial grid")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestSpawnSpecPlacement(t *testing.T) {
+	worldWidth, worldHeight = 800.0, 600.0
+	velocity := Vector2{X: 1.5, Y: -0.5}
+
+	rng := rand.New(rand.NewSource(1))
+	point := SpawnSpec{Mode: SpawnPoint, X: 100.0, Y: 200.0, Velocity: &velocity}
+	boid := point.spawnBoid(rng)
+	if boid.Position != (Vector2{X: 100.0, Y: 200.0}) {
+		t.Errorf("point spawn position = %v, want (100, 200)", boid.Position)
+	}
+	if boid.Velocity != velocity {
+		t.Errorf("point spawn velocity = %v, want %v", boid.Velocity, velocity)
+	}
+
+	rect := SpawnSpec{Mode: SpawnRect, X: 50.0, Y: 60.0, Width: 10.0, Height: 20.0}
+	for i := 0; i < 100; i++ {
+		p := rect.spawnBoid(rng).Position
+		if p.X < 50.0 || p.X > 60.0 || p.Y < 60.0 || p.Y > 80.0 {
+			t.Fatalf("rect spawn position = %v, outside spawn rect", p)
+		}
+	}
+}
+
+func TestInitSimulationFromIsReproducible(t *testing.T) {
+	initSimulationFrom(rand.New(rand.NewSource(9)), 50, 800.0, 600.0)
+	first := append([]float64(nil), flock.X...)
+	firstVX := append([]float64(nil), flock.VX...)
+
+	initSimulationFrom(rand.New(rand.NewSource(9)), 50, 800.0, 600.0)
+	for i := range first {
+		if flock.X[i] != first[i] || flock.VX[i] != firstVX[i] {
+			t.Fatalf("boid %d = (%v, %v), want (%v, %v) from the same seed", i, flock.X[i], flock.VX[i], first[i], firstVX[i])
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestSpawnSpecValidate(t *testing.T) {
+	if err := (SpawnSpec{Mode: "circle"}).Validate(); err == nil {
+		t.Errorf("Validate() with unknown mode error = nil, want error")
+	}
+	if err := (SpawnSpec{Mode: SpawnRect, Width: -1.0}).Validate(); err == nil {
+		t.Errorf("Validate() with negative width error = nil, want error")
+	}
+	if _, err := appendBoids(1, SpawnSpec{Mode: "circle"}); err == nil {
+		t.Errorf("appendBoids() with invalid spec error = nil, want error")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestTrimBoids(t *testing.T) {
+	initSimulation(5, 800.0, 600.0)
+	first := boidAt(0)
+
+	if err := trimBoids(2); err != nil {
+		t.Fatalf("trimBoids() error = %v", err)
+	}
+	if len(boids) != 3 || boidAt(0) != first {
+		t.Errorf("after trimBoids(2) count = %d, first = %v; want 3 and unchanged", len(boids), boidAt(0))
+	}
+
+	// Removing more than exist empties the flock
+	if err := trimBoids(10); err != nil {
+		t.Fatalf("trimBoids() error = %v", err)
+	}
+	if len(boids) != 0 {
+		t.Errorf("after trimBoids(10) count = %d, want 0", len(boids))
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestTrimBoidsAfterReorder(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulation(50, 800.0, 600.0)
+	added, err := appendBoids(5, SpawnSpec{Mode: SpawnRandom})
+	if err != nil {
+		t.Fatalf("appendBoids() error = %v", err)
+	}
+
+	// Step 0 reorders the flock, so the new boids are no longer at the end
+	stepCount = 0
+	stepSimulation()
+	if err := trimBoids(5); err != nil {
+		t.Fatalf("trimBoids() error = %v", err)
+	}
+
+	if len(boids) != 50 {
+		t.Errorf("count = %d, want 50", len(boids))
+	}
+	for _, id := range added {
+		if _, ok := lookupBoidIndex(id); ok {
+			t.Errorf("boid %d added last survived trimBoids(5)", id)
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	stepCount = 0
+}
+
+func TestDeleteBoidsAt(t *testing.T) {
+	initSimulation(5, 800.0, 600.0)
+	expected := []Boid{boidAt(0), boidAt(2), boidAt(4)}
+
+	if err := deleteBoidsAt([]int{3, 1, 3}); err != nil {
+		t.Fatalf("deleteBoidsAt() error = %v", err)
+	}
+	if len(boids) != len(expected) {
+		t.Fatalf("boid count = %d, want %d", len(boids), len(expected))
+	}
+	for i := range expected {
+		if boidAt(i) != expected[i] {
+			t.Errorf("boid %d = %v, want %v", i, boidAt(i), expected[i])
+		}
+	}
+
+	// Out of range indices reject the whole removal
+	if err := deleteBoidsAt([]int{0, 99}); err == nil {
+		t.Errorf("deleteBoidsAt() with invalid index error = nil, want error")
+	}
+	if len(boids) != 3 {
+		t.Errorf("boid count after rejected removal = %d, want 3", len(boids))
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestBoidIDsAreStable(t *testing.T) {
+	initSimulation(5, 800.0, 600.0)
+	tracked := boids[3].ID
+
+	seen := make(map[int]bool)
+	for _, boid := range boids {
+		if seen[boid.ID] {
+			t.Errorf("duplicate boid id %d", boid.ID)
+		}
+		seen[boid.ID] = true
+	}
+
+	if err := deleteBoidsByID([]int{boids[0].ID, boids[1].ID}); err != nil {
+		t.Fatalf("deleteBoidsByID() error = %v", err)
+	}
+
+	index, ok := lookupBoidIndex(tracked)
+	if !ok || index != 1 {
+		t.Errorf("lookupBoidIndex(%d) = (%d, %v), want (1, true)", tracked, index, ok)
+	}
+
+	// New boids never reuse an ID
+	ids, _ := appendBoids(2, SpawnSpec{Mode: SpawnRandom})
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("appended boid reused id %d", id)
+		}
+	}
+
+	if err := deleteBoidsByID([]int{-42}); err == nil {
+		t.Errorf("deleteBoidsByID() with unknown id error = nil, want error")
+	}
+	if _, ok := lookupBoidIndex(-42); ok {
+		t.Errorf("lookupBoidIndex(-42) found a boid, want none")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	boidIndex = nil
+}
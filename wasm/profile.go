@@ -0,0 +1,197 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Phase identifies a timed part of the engine
+type Phase int
+
+const (
+	PhaseReorder        Phase = iota // Z-order reordering of the boid slice
+	PhaseGridRebuild                 // spatial index Clear/Insert/Build
+	PhaseNeighborSearch              // spatial index queries, summed over workers
+	PhaseForces                      // whole force pass, including neighbor search
+	PhaseIntegration                 // ApplyForce, Update and WrapAround
+	PhaseExport                      // copying boid data out to JavaScript
+	phaseCount
+)
+
+// phaseNames are the keys used for each phase in getProfile
+var phaseNames = [phaseCount]string{
+	PhaseReorder:        "reorder",
+	PhaseGridRebuild:    "gridRebuild",
+	PhaseNeighborSearch: "neighborSearch",
+	PhaseForces:         "forces",
+	PhaseIntegration:    "integration",
+	PhaseExport:         "export",
+}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+// Profile accumulates engine counters since profiling was enabled or reset
+type Profile struct {
+	Steps              int
+	Durations          [phaseCount]time.Duration
+	CandidatesExamined int64  // boids returned by spatial index queries
+	CandidatesAccepted int64  // candidates within the rule's radius
+	Allocations        uint64 // heap objects allocated during steps
+	AllocatedBytes     uint64 // heap bytes allocated during steps
+}
+
+// Profiling state. Timing every neighbor query is not free, especially in
+// WASM where reading the clock calls into JavaScript, so it is opt-in.
+var (
+	profiling bool
+	profile   Profile
+	profileMu sync.Mutex // guards profile while force workers merge their counters
+
+	stepAlignmentNeighbors int64 // alignment neighbors found during the current step
+)
+
+// changeProfiling turns counters on or off and clears them
+func changeProfiling(enabled bool) {
+	profiling = enabled
+	resetProfile()
+}
+
+// resetProfile clears the accumulated counters
+func resetProfile() {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	profile = Profile{}
+}
+
+// phaseStart returns the start time of a phase, or the zero time when not profiling
+func phaseStart() time.Time {
+	if !profiling {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// phaseEnd adds the time since start to the phase
+func phaseEnd(phase Phase, start time.Time) {
+	if !profiling {
+		return
+	}
+	elapsed := time.Since(start)
+	profileMu.Lock()
+	profile.Durations[phase] += elapsed
+	profileMu.Unlock()
+}
+
+// readAllocations returns the heap allocation counters when profiling
+func readAllocations() (mallocs, bytes uint64) {
+	if !profiling {
+		return 0, 0
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Mallocs, stats.TotalAlloc
+}
+
+// recordStep adds one step and the allocations made since the given counters
+func recordStep(mallocs, bytes uint64) {
+	if !profiling {
+		return
+	}
+	nowMallocs, nowBytes := readAllocations()
+	profileMu.Lock()
+	profile.Steps++
+	profile.Allocations += nowMallocs - mallocs
+	profile.AllocatedBytes += nowBytes - bytes
+	profileMu.Unlock()
+}
+
+// neighborRule identifies the steering rule a neighbor query was made for
+type neighborRule int
+
+const (
+	ruleSeparation neighborRule = iota
+	ruleAlignment
+	ruleCohesion
+	ruleCount
+)
+
+// neighborStats counts neighbor queries for one worker. A nil *neighborStats
+// is valid and records nothing, which is what the rules get when neither
+// profiling, the stats history nor force debugging needs the counts.
+type neighborStats struct {
+	timed    bool // time each query, only when profiling
+	search   time.Duration
+	examined int64
+	accepted [ruleCount]int64
+}
+
+// newNeighborStats returns a counter for a worker, or nil when nothing reads the counts
+func newNeighborStats() *neighborStats {
+	if !profiling && !statsHistory.enabled() && !forceDebug {
+		return nil
+	}
+	return &neighborStats{timed: profiling}
+}
+
+// neighbors queries the spatial index, timing and counting the candidates
+func (s *neighborStats) neighbors(position Vector2, radius float64) []int {
+	if s == nil {
+		return spatialGrid.GetNeighbors(position, radius)
+	}
+	if !s.timed {
+		indices := spatialGrid.GetNeighbors(position, radius)
+		s.examined += int64(len(indices))
+		return indices
+	}
+
+	start := time.Now()
+	indices := spatialGrid.GetNeighbors(position, radius)
+	s.search += time.Since(start)
+	s.examined += int64(len(indices))
+	return indices
+}
+
+// accept records candidates that passed a rule's distance test
+func (s *neighborStats) accept(rule neighborRule, count int) {
+	if s != nil {
+		s.accepted[rule] += int64(count)
+	}
+}
+
+// merge adds a worker's counters to the global profile and the step's totals
+func (s *neighborStats) merge() {
+	if s == nil {
+		return
+	}
+	profileMu.Lock()
+	if profiling {
+		profile.Durations[PhaseNeighborSearch] += s.search
+		profile.CandidatesExamined += s.examined
+		for _, accepted := range s.accepted {
+			profile.CandidatesAccepted += accepted
+		}
+	}
+	stepAlignmentNeighbors += s.accepted[ruleAlignment]
+	profileMu.Unlock()
+}
+
+// profileToMap converts the profile to a JS-friendly map with times in milliseconds
+func profileToMap(p Profile) map[string]interface{} {
+	phases := make(map[string]interface{}, phaseCount)
+	for phase := Phase(0); phase < phaseCount; phase++ {
+		phases[phase.String()] = float64(p.Durations[phase]) / float64(time.Millisecond)
+	}
+
+	return map[string]interface{}{
+		"enabled":            profiling,
+		"steps":              p.Steps,
+		"phasesMs":           phases,
+		"candidatesExamined": float64(p.CandidatesExamined),
+		"candidatesAccepted": float64(p.CandidatesAccepted),
+		"allocations":        float64(p.Allocations),
+		"allocatedBytes":     float64(p.AllocatedBytes),
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestProfileCountsPhases(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(11)), 800, 800.0, 600.0)
+	changeProfiling(true)
+
+	for i := 0; i < 5; i++ {
+		stepSimulation()
+	}
+
+	if profile.Steps != 5 {
+		t.Errorf("profile.Steps = %d, want 5", profile.Steps)
+	}
+	for _, phase := range []Phase{PhaseReorder, PhaseGridRebuild, PhaseNeighborSearch, PhaseForces, PhaseIntegration} {
+		if profile.Durations[phase] <= 0 {
+			t.Errorf("profile.Durations[%v] = %v, want > 0", phase, profile.Durations[phase])
+		}
+	}
+	if profile.CandidatesAccepted <= 0 || profile.CandidatesExamined < profile.CandidatesAccepted {
+		t.Errorf("candidates examined %d, accepted %d, want 0 < accepted <= examined",
+			profile.CandidatesExamined, profile.CandidatesAccepted)
+	}
+
+	// Turning profiling off clears the counters and stops collecting
+	changeProfiling(false)
+	stepSimulation()
+	if profile != (Profile{}) {
+		t.Errorf("profile after disabling = %+v, want zero", profile)
+	}
+
+	// Reset global state
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestProfileCandidatesIndependentOfWorkers(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(13)), 2000, 800.0, 600.0)
+	initial := snapshotBoids()
+
+	counts := make(map[int][2]int64)
+	for _, workers := range []int{1, 4} {
+		changeProfiling(true)
+		runFlock(initial, workers, 3)
+		counts[workers] = [2]int64{profile.CandidatesExamined, profile.CandidatesAccepted}
+	}
+	if counts[1] != counts[4] {
+		t.Errorf("candidates with 4 workers = %v, want %v as with 1 worker", counts[4], counts[1])
+	}
+
+	// Reset global state
+	changeProfiling(false)
+	forceWorkers = defaultForceWorkers
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
@@ -0,0 +1,232 @@
+package main
+
+// Quadtree build limits
+const (
+	quadtreeLeafCapacity = 8
+	quadtreeMaxDepth     = 20 // stops subdividing boids stacked on the same point
+)
+
+// quadNode is a node of the quadtree covering order[start:end]
+type quadNode struct {
+	minX, minY, maxX, maxY float64
+	start, end             int
+	firstChild             int     // index of the first of four children, -1 for leaves
+	center                 Vector2 // center of mass of the boids in the node
+}
+
+// Quadtree is an adaptive spatial index that subdivides only where boids are
+// dense, so tight clusters don't degrade neighbor queries the way they do in
+// a uniform grid. The tree is rebuilt lazily on the first query after inserts.
+type Quadtree struct {
+	points   []Vector2 // position of each inserted boid, by boid index
+	inserted []int
+	order    []int
+	nodes    []quadNode
+	dirty    bool
+}
+
+// NewQuadtree creates an empty quadtree
+func NewQuadtree() *Quadtree {
+	return &Quadtree{}
+}
+
+// Clear removes every boid from the tree
+func (qt *Quadtree) Clear() {
+	qt.inserted = qt.inserted[:0]
+	qt.dirty = true
+}
+
+// Insert adds a boid to the tree
+func (qt *Quadtree) Insert(boidIndex int, position Vector2) {
+	qt.points = setPoint(qt.points, boidIndex, position)
+	qt.inserted = append(qt.inserted, boidIndex)
+	qt.dirty = true
+}
+
+// GetNeighbors returns the boid indices within radius
+func (qt *Quadtree) GetNeighbors(position Vector2, radius float64) []int {
+	return qt.QueryRadius(position, radius)
+}
+
+// QueryRadius returns the boid indices strictly within radius
+func (qt *Quadtree) QueryRadius(position Vector2, radius float64) []int {
+	qt.Build()
+	result := make([]int, 0, 20)
+	if len(qt.nodes) == 0 {
+		return result
+	}
+
+	radiusSquared := radius * radius
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &qt.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		if boxDistanceSquared(position, node.minX, node.minY, node.maxX, node.maxY) >= radiusSquared {
+			continue
+		}
+		if node.firstChild < 0 {
+			for _, index := range qt.order[node.start:node.end] {
+				if qt.points[index].DistanceSquared(position) < radiusSquared {
+					result = append(result, index)
+				}
+			}
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			stack = append(stack, node.firstChild+c)
+		}
+	}
+	return result
+}
+
+// KNearest returns up to k boid indices ordered by increasing distance
+func (qt *Quadtree) KNearest(position Vector2, k int) []int {
+	qt.Build()
+	if k <= 0 || len(qt.nodes) == 0 {
+		return nil
+	}
+
+	best := newNearestSet(k)
+	qt.nearest(0, position, best)
+	return best.indices()
+}
+
+// nearest searches the subtree, visiting the closer children first
+func (qt *Quadtree) nearest(nodeIndex int, position Vector2, best *nearestSet) {
+	node := qt.nodes[nodeIndex]
+	if node.firstChild < 0 {
+		for _, index := range qt.order[node.start:node.end] {
+			best.offer(index, qt.points[index].DistanceSquared(position))
+		}
+		return
+	}
+
+	var children [4]int
+	var distances [4]float64
+	for c := 0; c < 4; c++ {
+		child := qt.nodes[node.firstChild+c]
+		children[c] = node.firstChild + c
+		distances[c] = boxDistanceSquared(position, child.minX, child.minY, child.maxX, child.maxY)
+	}
+	// Sort the four children by distance
+	for i := 1; i < 4; i++ {
+		for j := i; j > 0 && distances[j] < distances[j-1]; j-- {
+			distances[j], distances[j-1] = distances[j-1], distances[j]
+			children[j], children[j-1] = children[j-1], children[j]
+		}
+	}
+
+	for c := 0; c < 4; c++ {
+		if distances[c] > best.worst() {
+			return
+		}
+		qt.nearest(children[c], position, best)
+	}
+}
+
+// QueryRect returns the boid indices inside the rectangle
+func (qt *Quadtree) QueryRect(rect Rect) []int {
+	qt.Build()
+	result := make([]int, 0, 64)
+	if len(qt.nodes) == 0 {
+		return result
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &qt.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		if node.maxX < rect.X || node.minX > rect.X+rect.Width || node.maxY < rect.Y || node.minY > rect.Y+rect.Height {
+			continue
+		}
+		if node.firstChild < 0 {
+			for _, index := range qt.order[node.start:node.end] {
+				if rect.Contains(qt.points[index]) {
+					result = append(result, index)
+				}
+			}
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			stack = append(stack, node.firstChild+c)
+		}
+	}
+	return result
+}
+
+// Build rebuilds the tree from the inserted boids if anything changed
+func (qt *Quadtree) Build() {
+	if !qt.dirty {
+		return
+	}
+	qt.dirty = false
+	qt.nodes = qt.nodes[:0]
+	qt.order = append(qt.order[:0], qt.inserted...)
+	if len(qt.order) == 0 {
+		return
+	}
+
+	minX, minY := qt.points[qt.order[0]].X, qt.points[qt.order[0]].Y
+	maxX, maxY := minX, minY
+	for _, index := range qt.order {
+		p := qt.points[index]
+		minX, maxX = min(minX, p.X), max(maxX, p.X)
+		minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+	}
+
+	qt.nodes = append(qt.nodes, quadNode{})
+	qt.buildNode(0, minX, minY, maxX, maxY, 0, len(qt.order), 0)
+}
+
+// buildNode fills the node at nodeIndex and subdivides it if it holds too many boids
+func (qt *Quadtree) buildNode(nodeIndex int, minX, minY, maxX, maxY float64, start, end, depth int) {
+	qt.nodes[nodeIndex] = quadNode{minX: minX, minY: minY, maxX: maxX, maxY: maxY, start: start, end: end, firstChild: -1}
+	if end-start <= quadtreeLeafCapacity || depth >= quadtreeMaxDepth {
+		sum := Vector2{X: 0, Y: 0}
+		for _, index := range qt.order[start:end] {
+			sum = sum.Add(qt.points[index])
+		}
+		qt.nodes[nodeIndex].center = sum.Div(float64(end - start))
+		return
+	}
+
+	midX := (minX + maxX) / 2
+	midY := (minY + maxY) / 2
+	items := qt.order[start:end]
+
+	// Split top/bottom, then each half left/right, giving quadrants NW, NE, SW, SE
+	splitY := start + partitionIndices(items, func(i int) bool { return qt.points[i].Y < midY })
+	splitTop := start + partitionIndices(qt.order[start:splitY], func(i int) bool { return qt.points[i].X < midX })
+	splitBottom := splitY + partitionIndices(qt.order[splitY:end], func(i int) bool { return qt.points[i].X < midX })
+
+	firstChild := len(qt.nodes)
+	qt.nodes[nodeIndex].firstChild = firstChild
+	qt.nodes = append(qt.nodes, quadNode{}, quadNode{}, quadNode{}, quadNode{})
+
+	qt.buildNode(firstChild, minX, minY, midX, midY, start, splitTop, depth+1)
+	qt.buildNode(firstChild+1, midX, minY, maxX, midY, splitTop, splitY, depth+1)
+	qt.buildNode(firstChild+2, minX, midY, midX, maxY, splitY, splitBottom, depth+1)
+	qt.buildNode(firstChild+3, midX, midY, maxX, maxY, splitBottom, end, depth+1)
+
+	// Combine the children's centers of mass, weighted by boid count
+	sum := Vector2{X: 0, Y: 0}
+	for c := 0; c < 4; c++ {
+		child := qt.nodes[firstChild+c]
+		sum = sum.Add(child.center.Mul(float64(child.end - child.start)))
+	}
+	qt.nodes[nodeIndex].center = sum.Div(float64(end - start))
+}
+
+// partitionIndices moves the items matching pred to the front and returns how many matched
+func partitionIndices(items []int, pred func(int) bool) int {
+	split := 0
+	for i, index := range items {
+		if pred(index) {
+			items[i], items[split] = items[split], items[i]
+			split++
+		}
+	}
+	return split
+}
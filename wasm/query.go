@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultHitRadius is how close a ray must pass to a boid's center to hit it
+// when the caller gives no radius, roughly the size a boid is drawn at
+const defaultHitRadius = 5.0
+
+// RayHit is the first boid a segment runs into
+type RayHit struct {
+	Index    int
+	T        float64 // fraction of the segment travelled before the hit
+	Distance float64 // distance from the segment start to the hit point
+	Point    Vector2
+}
+
+// findNearestBoid returns the index of the boid closest to position and its
+// distance. maxDist of 0 or less searches the whole flock; otherwise only
+// boids strictly closer than maxDist count.
+func findNearestBoid(position Vector2, maxDist float64) (int, float64, bool) {
+	if len(boids) == 0 {
+		return 0, 0, false
+	}
+	ensureSpatialGrid()
+
+	var candidates []int
+	if maxDist > 0 {
+		candidates = spatialGrid.QueryRadius(position, maxDist)
+	} else {
+		candidates = spatialGrid.KNearest(position, 1)
+	}
+
+	best, bestDistance := -1, math.Inf(1)
+	for _, i := range candidates {
+		d := position.Distance(flock.Position(i))
+		if d < bestDistance || (d == bestDistance && i < best) {
+			best, bestDistance = i, d
+		}
+	}
+	return best, bestDistance, best >= 0
+}
+
+// queryBoidsInCircle returns the indices of boids strictly within radius of center
+func queryBoidsInCircle(center Vector2, radius float64) ([]int, error) {
+	if math.IsNaN(radius) || radius < 0 {
+		return nil, fmt.Errorf("query radius must not be negative, got %v", radius)
+	}
+	ensureSpatialGrid()
+	return spatialGrid.QueryRadius(center, radius), nil
+}
+
+// queryBoidsInRect returns the indices of boids inside rect, edges included
+func queryBoidsInRect(rect Rect) ([]int, error) {
+	if !(rect.Width >= 0) || !(rect.Height >= 0) {
+		return nil, fmt.Errorf("query rectangle must have a non-negative size, got %vx%v", rect.Width, rect.Height)
+	}
+	ensureSpatialGrid()
+
+	candidates := spatialGrid.QueryRect(rect)
+	inside := candidates[:0]
+	for _, i := range candidates {
+		if rect.Contains(flock.Position(i)) {
+			inside = append(inside, i)
+		}
+	}
+	return inside, nil
+}
+
+// castRay returns the first boid whose hit circle the segment from a to b
+// enters. A segment starting inside a circle hits it at the start. Only
+// boids near the segment's bounding box are tested, so short rays are cheap.
+func castRay(a, b Vector2, hitRadius float64) (RayHit, bool, error) {
+	if math.IsNaN(hitRadius) || hitRadius <= 0 {
+		return RayHit{}, false, fmt.Errorf("hit radius must be positive, got %v", hitRadius)
+	}
+	ensureSpatialGrid()
+
+	bounds := Rect{
+		X:      math.Min(a.X, b.X) - hitRadius,
+		Y:      math.Min(a.Y, b.Y) - hitRadius,
+		Width:  math.Abs(b.X-a.X) + 2*hitRadius,
+		Height: math.Abs(b.Y-a.Y) + 2*hitRadius,
+	}
+	d := b.Sub(a)
+	length2 := d.X*d.X + d.Y*d.Y
+	radius2 := hitRadius * hitRadius
+
+	hit := RayHit{Index: -1, T: math.Inf(1)}
+	for _, i := range spatialGrid.QueryRect(bounds) {
+		// Solve |a + t*d - c|² = r² for the first t in [0, 1]
+		f := a.Sub(flock.Position(i))
+		c := f.X*f.X + f.Y*f.Y - radius2
+		var t float64
+		if c <= 0 {
+			t = 0
+		} else {
+			if length2 == 0 {
+				continue
+			}
+			half := f.X*d.X + f.Y*d.Y
+			disc := half*half - length2*c
+			if half >= 0 || disc < 0 {
+				continue // moving away from the circle, or passing it by
+			}
+			t = (-half - math.Sqrt(disc)) / length2
+			if t > 1 {
+				continue
+			}
+		}
+		if t < hit.T || (t == hit.T && i < hit.Index) {
+			hit.Index, hit.T = i, t
+		}
+	}
+	if hit.Index < 0 {
+		return RayHit{}, false, nil
+	}
+
+	hit.Point = a.Add(d.Mul(hit.T))
+	hit.Distance = math.Sqrt(length2) * hit.T
+	return hit, true, nil
+}
+
+// boidIDsAt maps boid indices to their IDs
+func boidIDsAt(indices []int) []int32 {
+	ids := make([]int32, len(indices))
+	for i, index := range indices {
+		ids[i] = int32(boids[index].ID)
+	}
+	return ids
+}
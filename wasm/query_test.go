@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// placeQueryBoids lays out a small flock with known IDs for the query tests,
+// on a fresh grid in a wrapped world whatever earlier tests left behind
+func placeQueryBoids() {
+	params = DefaultSimulationParams()
+	worldWidth, worldHeight = 800.0, 600.0
+	spatialIndexKind = IndexGrid
+	boundaryMode = BoundaryWrap
+	spatialGrid = nil
+	loadBoids([]Boid{
+		{ID: 1, Position: Vector2{X: 100, Y: 100}},
+		{ID: 2, Position: Vector2{X: 130, Y: 100}},
+		{ID: 3, Position: Vector2{X: 400, Y: 300}},
+		{ID: 4, Position: Vector2{X: 700, Y: 500}},
+	})
+	reindexBoids()
+}
+
+func TestNearestBoid(t *testing.T) {
+	placeQueryBoids()
+
+	tests := []struct {
+		name     string
+		at       Vector2
+		maxDist  float64
+		wantID   int
+		wantDist float64
+		wantOK   bool
+	}{
+		{"within range", Vector2{X: 120, Y: 100}, 50, 2, 10, true},
+		{"out of range", Vector2{X: 250, Y: 200}, 50, 0, 0, false},
+		{"unlimited", Vector2{X: 650, Y: 500}, 0, 4, 50, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, dist, ok := findNearestBoid(tt.at, tt.maxDist)
+			if ok != tt.wantOK {
+				t.Fatalf("findNearestBoid() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (boids[index].ID != tt.wantID || dist != tt.wantDist) {
+				t.Errorf("findNearestBoid() = id %d at %v, want id %d at %v", boids[index].ID, dist, tt.wantID, tt.wantDist)
+			}
+		})
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestBoidsInCircleAndRect(t *testing.T) {
+	placeQueryBoids()
+
+	inCircle, err := queryBoidsInCircle(Vector2{X: 110, Y: 100}, 25)
+	if err != nil {
+		t.Fatalf("queryBoidsInCircle() error: %v", err)
+	}
+	if ids := boidIDsAt(inCircle); len(ids) != 2 {
+		t.Errorf("queryBoidsInCircle() = %v, want boids 1 and 2", ids)
+	}
+
+	inRect, err := queryBoidsInRect(Rect{X: 100, Y: 100, Width: 300, Height: 200})
+	if err != nil {
+		t.Fatalf("queryBoidsInRect() error: %v", err)
+	}
+	// Edges count as inside, boid 4 is well outside
+	if ids := boidIDsAt(inRect); len(ids) != 3 {
+		t.Errorf("queryBoidsInRect() = %v, want boids 1, 2 and 3", ids)
+	}
+
+	if _, err := queryBoidsInRect(Rect{Width: -1, Height: 10}); err == nil {
+		t.Error("queryBoidsInRect() accepted a negative width")
+	}
+	if _, err := queryBoidsInCircle(Vector2{}, math.NaN()); err == nil {
+		t.Error("queryBoidsInCircle() accepted a NaN radius")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestRaycast(t *testing.T) {
+	placeQueryBoids()
+
+	tests := []struct {
+		name     string
+		from, to Vector2
+		wantID   int
+		wantDist float64
+		wantHit  bool
+	}{
+		{"first along the ray", Vector2{X: 200, Y: 100}, Vector2{X: 0, Y: 100}, 2, 65, true},
+		{"reversed", Vector2{X: 0, Y: 100}, Vector2{X: 200, Y: 100}, 1, 95, true},
+		{"misses", Vector2{X: 0, Y: 200}, Vector2{X: 800, Y: 200}, 0, 0, false},
+		{"ends short", Vector2{X: 300, Y: 300}, Vector2{X: 390, Y: 300}, 0, 0, false},
+		{"starts inside", Vector2{X: 401, Y: 300}, Vector2{X: 600, Y: 300}, 3, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, ok, err := castRay(tt.from, tt.to, 5)
+			if err != nil {
+				t.Fatalf("castRay() error: %v", err)
+			}
+			if ok != tt.wantHit {
+				t.Fatalf("castRay() hit = %v, want %v", ok, tt.wantHit)
+			}
+			if ok && (boids[hit.Index].ID != tt.wantID || math.Abs(hit.Distance-tt.wantDist) > 1e-9) {
+				t.Errorf("castRay() = id %d at %v, want id %d at %v", boids[hit.Index].ID, hit.Distance, tt.wantID, tt.wantDist)
+			}
+		})
+	}
+
+	if _, _, err := castRay(Vector2{}, Vector2{X: 1}, 0); err == nil {
+		t.Error("castRay() accepted a zero hit radius")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
@@ -1,6 +1,9 @@
 package main
 
-import "math"
+import (
+	"math"
+	"time"
+)
 
 // SimulationParams holds all simulation parameters
 type SimulationParams struct {
@@ -11,37 +14,199 @@ type SimulationParams struct {
 	CohesionRadius         float64
 	CohesionStrength       float64
 	MouseAvoidanceDistance float64
+	BarnesHutTheta         float64 // opening angle for approximate cohesion
+	BarnesHutRadius        float64 // cohesion radius at which approximation kicks in, 0 disables it
 }
 
 // Global state
 var (
-	boids        []Boid
+	boids        []boidAttrs // per-boid attributes, index-aligned with flock
 	params       SimulationParams
-	canvasWidth  float64 = 800.0
-	canvasHeight float64 = 600.0
+	worldWidth   float64 = 800.0
+	worldHeight  float64 = 600.0
+	camera       Camera  = NewCamera(800.0, 600.0)
 	mousePos     Vector2 = Vector2{X: -1000.0, Y: -1000.0}
-	spatialGrid  *SpatialGrid
+	spatialGrid  SpatialIndex // active spatial index, selected by spatialIndexKind
+	boundaryMode BoundaryMode = BoundaryWrap
+	nextBoidID   int         // IDs are assigned monotonically and never reused
+	boidIndex    map[int]int // boid ID -> current index into boids
+	flock        BoidArrays  // positions and velocities of every boid
+	precision    Precision   = Precision64
+
+	spatialIndexKind SpatialIndexKind = IndexGrid
+	spatialCellSize  float64          = defaultCellSize
+	spatialGridStale bool             // boids moved, were added or removed since the index was built
+	cohesionTree     *Quadtree        // center-of-mass tree for Barnes–Hut cohesion
+
+	forceCursor           int                                // next boid to recompute when steps are budgeted
+	forceWorkers          int = defaultForceWorkers          // goroutines computing forces; serial in js/wasm
+	stepCount             int                                // number of completed simulation steps
+	mortonReorderInterval int = defaultMortonReorderInterval // steps between Z-order reorders, 0 disables
+
+	domain *Domain // strip of the world owned by this engine, nil owns everything
+	ghosts []Boid  // neighbor boids imported for the next step only
 )
 
+// StepBudget limits how much force computation a single step may do.
+// Zero fields mean no limit.
+type StepBudget struct {
+	Time  time.Duration // wall-clock time for the whole step
+	Boids int           // number of boids whose forces are recomputed
+}
+
+// StepReport describes how much of the flock a step actually recomputed
+type StepReport struct {
+	Computed int           // boids whose forces were recomputed
+	Count    int           // boids in the flock
+	Elapsed  time.Duration // wall-clock time of the step
+}
+
+// Fraction is the share of the flock that received fresh forces
+func (r StepReport) Fraction() float64 {
+	if r.Count == 0 {
+		return 1
+	}
+	return float64(r.Computed) / float64(r.Count)
+}
+
+// stepSimulation advances the flock by one frame
+func stepSimulation() {
+	stepWithBudget(StepBudget{})
+}
+
+// stepWithBudget advances the flock by one frame, recomputing forces for as
+// many boids as the budget allows. The rest reuse their last forces, and the
+// boids recomputed rotate from step to step so none goes stale for long.
+func stepWithBudget(budget StepBudget) StepReport {
+	start := time.Now()
+	mallocs, allocBytes := readAllocations()
+	stepAlignmentNeighbors = 0
+	tracer.beginStep(stepCount)
+
+	// Keep boids that are close in space close in memory
+	if mortonReorderInterval > 0 && stepCount%mortonReorderInterval == 0 {
+		phase := phaseStart()
+		tracer.begin("reorder")
+		reorderBoidsMorton()
+		tracer.end("reorder")
+		phaseEnd(PhaseReorder, phase)
+	}
+
+	// Ghosts take part in neighbor searches but are never integrated
+	owned := len(boids)
+	for _, ghost := range ghosts {
+		appendBoid(ghost)
+	}
+
+	// Clear and rebuild spatial grid
+	phase := phaseStart()
+	rebuildSpatialGrid()
+	phaseEnd(PhaseGridRebuild, phase)
+
+	// Calculate flocking forces from the snapshot, in parallel where supported
+	phase = phaseStart()
+	tracer.begin("forces")
+	computed := computeForces(owned, budget, start)
+	tracer.end("forces")
+	phaseEnd(PhaseForces, phase)
+	truncateBoids(owned)
+	ghosts = ghosts[:0]
+
+	// Update each boid
+	phase = phaseStart()
+	if tracer != nil {
+		integrateTraced()
+	} else {
+		integrate()
+	}
+	phaseEnd(PhaseIntegration, phase)
+	heatmap.accumulate()
+	trails.record()
+	recordStep(mallocs, allocBytes)
+	stepCount++
+
+	if clusterOptions.Interval > 0 && stepCount%clusterOptions.Interval == 0 {
+		tracer.begin("clusters")
+		detectClusters()
+		tracer.end("clusters")
+	}
+	recordStepStats(computed)
+	tracer.endStep()
+	return StepReport{Computed: computed, Count: owned, Elapsed: time.Since(start)}
+}
+
+// integrate applies each boid's steering force and moves it
+func integrate() {
+	for i := range boids {
+		boid := boidAt(i)
+
+		// Apply forces
+		boid.ApplyForce(boid.Steering)
+
+		// Update position
+		boid.Update()
+
+		// Handle boundaries
+		if boundaryMode == BoundaryWrap {
+			boid.WrapAround(worldWidth, worldHeight)
+		}
+		storeBoid(i, boid)
+	}
+}
+
+// integrateTraced does the same work as integrate in separate passes so the
+// tracer can time Update and WrapAround individually. Boids are only stored
+// at the end, so float32 rounding happens at the same point as in integrate.
+func integrateTraced() {
+	views := make([]Boid, len(boids))
+	tracer.begin("Update")
+	for i := range views {
+		views[i] = boidAt(i)
+		views[i].ApplyForce(views[i].Steering)
+		views[i].Update()
+	}
+	tracer.end("Update")
+
+	if boundaryMode == BoundaryWrap {
+		tracer.begin("WrapAround")
+		for i := range views {
+			views[i].WrapAround(worldWidth, worldHeight)
+		}
+		tracer.end("WrapAround")
+	}
+
+	tracer.begin("store")
+	for i := range views {
+		storeBoid(i, views[i])
+	}
+	tracer.end("store")
+}
+
+// withinRuleRadius is the distance test every rule applies to candidates;
+// coincident boids are skipped because they have no direction
+func withinRuleRadius(distanceSquared, radiusSquared float64) bool {
+	return distanceSquared > 0 && distanceSquared < radiusSquared
+}
+
 // Optimized flocking behaviors using spatial grid
-func (b *Boid) separate(boidIndex int) Vector2 {
+func (b *Boid) separate(boidIndex int, stats *neighborStats) Vector2 {
 	steer := Vector2{X: 0, Y: 0}
 	count := 0
 	separationRadiusSquared := params.SeparationRadius * params.SeparationRadius
 
 	// Get nearby boids using spatial grid
-	nearbyIndices := spatialGrid.GetNeighbors(b.Position, params.SeparationRadius)
+	nearbyIndices := stats.neighbors(b.Position, params.SeparationRadius)
 	
 	for _, otherIndex := range nearbyIndices {
 		if otherIndex == boidIndex {
 			continue // Skip self
 		}
 		
-		other := &boids[otherIndex]
-		distanceSquared := b.Position.DistanceSquared(other.Position)
-		if distanceSquared > 0 && distanceSquared < separationRadiusSquared {
+		otherPosition := flock.Position(otherIndex)
+		distanceSquared := b.Position.DistanceSquared(otherPosition)
+		if withinRuleRadius(distanceSquared, separationRadiusSquared) {
 			distance := math.Sqrt(distanceSquared)
-			diff := b.Position.Sub(other.Position)
+			diff := b.Position.Sub(otherPosition)
 			diff = diff.Normalize()
 			diff = diff.Div(distance) // Weight by distance
 			steer = steer.Add(diff)
@@ -49,6 +214,7 @@ func (b *Boid) separate(boidIndex int) Vector2 {
 		}
 	}
 
+	stats.accept(ruleSeparation, count)
 	if count > 0 {
 		steer = steer.Div(float64(count))
 		steer = steer.Normalize()
@@ -61,27 +227,27 @@ func (b *Boid) separate(boidIndex int) Vector2 {
 	return Vector2{X: 0, Y: 0}
 }
 
-func (b *Boid) align(boidIndex int) Vector2 {
+func (b *Boid) align(boidIndex int, stats *neighborStats) Vector2 {
 	sum := Vector2{X: 0, Y: 0}
 	count := 0
 	alignmentRadiusSquared := params.AlignmentRadius * params.AlignmentRadius
 
 	// Get nearby boids using spatial grid
-	nearbyIndices := spatialGrid.GetNeighbors(b.Position, params.AlignmentRadius)
+	nearbyIndices := stats.neighbors(b.Position, params.AlignmentRadius)
 	
 	for _, otherIndex := range nearbyIndices {
 		if otherIndex == boidIndex {
 			continue // Skip self
 		}
 		
-		other := &boids[otherIndex]
-		distanceSquared := b.Position.DistanceSquared(other.Position)
-		if distanceSquared > 0 && distanceSquared < alignmentRadiusSquared {
-			sum = sum.Add(other.Velocity)
+		distanceSquared := b.Position.DistanceSquared(flock.Position(otherIndex))
+		if withinRuleRadius(distanceSquared, alignmentRadiusSquared) {
+			sum = sum.Add(flock.Velocity(otherIndex))
 			count++
 		}
 	}
 
+	stats.accept(ruleAlignment, count)
 	if count > 0 {
 		sum = sum.Div(float64(count))
 		sum = sum.Normalize()
@@ -94,27 +260,33 @@ func (b *Boid) align(boidIndex int) Vector2 {
 	return Vector2{X: 0, Y: 0}
 }
 
-func (b *Boid) cohesion(boidIndex int) Vector2 {
+func (b *Boid) cohesion(boidIndex int, stats *neighborStats) Vector2 {
+	// Large radii make the neighbor scan O(n²); approximate far-field attraction instead
+	if barnesHutActive() && cohesionTree != nil {
+		return b.cohesionBarnesHut(boidIndex)
+	}
+
 	sum := Vector2{X: 0, Y: 0}
 	count := 0
 	cohesionRadiusSquared := params.CohesionRadius * params.CohesionRadius
 
 	// Get nearby boids using spatial grid
-	nearbyIndices := spatialGrid.GetNeighbors(b.Position, params.CohesionRadius)
+	nearbyIndices := stats.neighbors(b.Position, params.CohesionRadius)
 	
 	for _, otherIndex := range nearbyIndices {
 		if otherIndex == boidIndex {
 			continue // Skip self
 		}
 		
-		other := &boids[otherIndex]
-		distanceSquared := b.Position.DistanceSquared(other.Position)
-		if distanceSquared > 0 && distanceSquared < cohesionRadiusSquared {
-			sum = sum.Add(other.Position)
+		otherPosition := flock.Position(otherIndex)
+		distanceSquared := b.Position.DistanceSquared(otherPosition)
+		if withinRuleRadius(distanceSquared, cohesionRadiusSquared) {
+			sum = sum.Add(otherPosition)
 			count++
 		}
 	}
 
+	stats.accept(ruleCohesion, count)
 	if count > 0 {
 		center := sum.Div(float64(count))
 		return b.seek(center).Mul(params.CohesionStrength)
@@ -36,15 +36,12 @@ func TestBoidSeparation(t *testing.T) {
 	
 	boid2 := NewBoid(10.0, 0.0) // Close to boid1
 	
-	boids = []Boid{boid1, boid2}
+	loadBoids([]Boid{boid1, boid2})
 	
 	// Populate spatial grid
-	spatialGrid.Clear()
-	for i := range boids {
-		spatialGrid.Insert(i, boids[i].Position)
-	}
+	rebuildSpatialGrid()
 	
-	force := boid1.separate(0)
+	force := boid1.separate(0, nil)
 	
 	// Force should point away from the other boid (negative X direction)
 	if force.X >= 0 {
@@ -52,7 +49,7 @@ func TestBoidSeparation(t *testing.T) {
 	}
 	
 	// Reset global state
-	boids = nil
+	loadBoids(nil)
 	spatialGrid = nil
 }
 
@@ -75,15 +72,12 @@ func TestBoidAlignment(t *testing.T) {
 	boid2 := NewBoid(30.0, 0.0)
 	boid2.Velocity = Vector2{X: 1.0, Y: 0.0}
 	
-	boids = []Boid{boid1, boid2}
+	loadBoids([]Boid{boid1, boid2})
 	
 	// Populate spatial grid
-	spatialGrid.Clear()
-	for i := range boids {
-		spatialGrid.Insert(i, boids[i].Position)
-	}
+	rebuildSpatialGrid()
 	
-	force := boid1.align(0)
+	force := boid1.align(0, nil)
 	
 	// Force should point in the direction of other boids' velocities
 	if force.X <= 0 {
@@ -91,7 +85,7 @@ func TestBoidAlignment(t *testing.T) {
 	}
 	
 	// Reset global state
-	boids = nil
+	loadBoids(nil)
 	spatialGrid = nil
 }
 
@@ -113,15 +107,12 @@ func TestBoidCohesion(t *testing.T) {
 	
 	boid2 := NewBoid(30.0, 0.0)
 	
-	boids = []Boid{boid1, boid2}
+	loadBoids([]Boid{boid1, boid2})
 	
 	// Populate spatial grid
-	spatialGrid.Clear()
-	for i := range boids {
-		spatialGrid.Insert(i, boids[i].Position)
-	}
+	rebuildSpatialGrid()
 	
-	force := boid1.cohesion(0)
+	force := boid1.cohesion(0, nil)
 	
 	// Force should point toward the center of other boids
 	if force.X <= 0 {
@@ -129,7 +120,7 @@ func TestBoidCohesion(t *testing.T) {
 	}
 	
 	// Reset global state
-	boids = nil
+	loadBoids(nil)
 	spatialGrid = nil
 }
 
@@ -182,18 +173,15 @@ func TestBoidNoSelfInteraction(t *testing.T) {
 	boid := NewBoid(0.0, 0.0)
 	boid.Velocity = Vector2{X: 1.0, Y: 0.0}
 	
-	boids = []Boid{boid}
+	loadBoids([]Boid{boid})
 	
 	// Populate spatial grid
-	spatialGrid.Clear()
-	for i := range boids {
-		spatialGrid.Insert(i, boids[i].Position)
-	}
+	rebuildSpatialGrid()
 	
 	// Test that boid doesn't interact with itself
-	sepForce := boid.separate(0)
-	alignForce := boid.align(0)
-	cohForce := boid.cohesion(0)
+	sepForce := boid.separate(0, nil)
+	alignForce := boid.align(0, nil)
+	cohForce := boid.cohesion(0, nil)
 	
 	zeroVec := Vector2{X: 0.0, Y: 0.0}
 	
@@ -210,7 +198,7 @@ func TestBoidNoSelfInteraction(t *testing.T) {
 	}
 	
 	// Reset global state
-	boids = nil
+	loadBoids(nil)
 	spatialGrid = nil
 }
 
@@ -234,17 +222,14 @@ func TestBoidForcesOutsideRadius(t *testing.T) {
 	
 	boid2 := NewBoid(100.0, 0.0) // Far from boid1
 	
-	boids = []Boid{boid1, boid2}
+	loadBoids([]Boid{boid1, boid2})
 	
 	// Populate spatial grid
-	spatialGrid.Clear()
-	for i := range boids {
-		spatialGrid.Insert(i, boids[i].Position)
-	}
+	rebuildSpatialGrid()
 	
-	sepForce := boid1.separate(0)
-	alignForce := boid1.align(0)
-	cohForce := boid1.cohesion(0)
+	sepForce := boid1.separate(0, nil)
+	alignForce := boid1.align(0, nil)
+	cohForce := boid1.cohesion(0, nil)
 	
 	zeroVec := Vector2{X: 0.0, Y: 0.0}
 	
@@ -261,6 +246,6 @@ func TestBoidForcesOutsideRadius(t *testing.T) {
 	}
 	
 	// Reset global state
-	boids = nil
+	loadBoids(nil)
 	spatialGrid = nil
 }
\ No newline at end of file
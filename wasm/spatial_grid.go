@@ -10,6 +10,8 @@ type SpatialGrid struct {
 	cols     int
 	rows     int
 	cells    [][]int // 1D array of cells, each containing boid indices
+	points   []Vector2 // position of each inserted boid, by boid index
+	count    int       // number of boids stored in cells
 }
 
 // NewSpatialGrid creates a new spatial grid
@@ -38,17 +40,23 @@ func (sg *SpatialGrid) Clear() {
 	for i := range sg.cells {
 		sg.cells[i] = sg.cells[i][:0] // reset slice but keep capacity
 	}
+	sg.count = 0
 }
 
 // Insert adds a boid to the grid
 func (sg *SpatialGrid) Insert(boidIndex int, position Vector2) {
+	// Boids on the far edge or outside the grid go to the nearest border cell
 	row, col := sg.getCellCoords(position)
-	if sg.isValidCell(row, col) {
-		cellIndex := row * sg.cols + col
-		sg.cells[cellIndex] = append(sg.cells[cellIndex], boidIndex)
-	}
+	row, col = min(max(row, 0), sg.rows-1), min(max(col, 0), sg.cols-1)
+	cellIndex := row * sg.cols + col
+	sg.cells[cellIndex] = append(sg.cells[cellIndex], boidIndex)
+	sg.points = setPoint(sg.points, boidIndex, position)
+	sg.count++
 }
 
+// Build is a no-op; the grid is always ready to query
+func (sg *SpatialGrid) Build() {}
+
 // GetNeighbors returns all boid indices in cells within the given radius
 func (sg *SpatialGrid) GetNeighbors(position Vector2, radius float64) []int {
 	neighbors := make([]int, 0, 20) // pre-allocate
@@ -57,8 +65,9 @@ func (sg *SpatialGrid) GetNeighbors(position Vector2, radius float64) []int {
 	cellRadius := int(math.Ceil(radius / sg.cellSize))
 	centerRow, centerCol := sg.getCellCoords(position)
 	
-	for row := centerRow - cellRadius; row <= centerRow + cellRadius; row++ {
-		for col := centerCol - cellRadius; col <= centerCol + cellRadius; col++ {
+	// Only visit rows and columns that exist in the grid
+	for row := max(centerRow - cellRadius, 0); row <= min(centerRow + cellRadius, sg.rows-1); row++ {
+		for col := max(centerCol - cellRadius, 0); col <= min(centerCol + cellRadius, sg.cols-1); col++ {
 			if sg.isValidCell(row, col) {
 				cellIndex := row * sg.cols + col
 				neighbors = append(neighbors, sg.cells[cellIndex]...)
@@ -69,6 +78,36 @@ func (sg *SpatialGrid) GetNeighbors(position Vector2, radius float64) []int {
 	return neighbors
 }
 
+// QueryRadius returns the boid indices strictly within radius
+func (sg *SpatialGrid) QueryRadius(position Vector2, radius float64) []int {
+	return filterWithinRadius(sg.GetNeighbors(position, radius), sg.points, position, radius)
+}
+
+// KNearest returns up to k boid indices ordered by increasing distance
+func (sg *SpatialGrid) KNearest(position Vector2, k int) []int {
+	return kNearestByExpansion(func(radius float64) []int {
+		return sg.QueryRadius(position, radius)
+	}, sg.points, position, k, sg.count, sg.cellSize)
+}
+
+// QueryRect returns all boid indices in cells overlapping the rectangle
+func (sg *SpatialGrid) QueryRect(rect Rect) []int {
+	result := make([]int, 0, 64)
+
+	minRow, minCol := sg.getCellCoords(Vector2{X: rect.X, Y: rect.Y})
+	maxRow, maxCol := sg.getCellCoords(Vector2{X: rect.X + rect.Width, Y: rect.Y + rect.Height})
+	minRow, minCol = max(minRow, 0), max(minCol, 0)
+	maxRow, maxCol = min(maxRow, sg.rows-1), min(maxCol, sg.cols-1)
+
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			result = append(result, sg.cells[row*sg.cols+col]...)
+		}
+	}
+
+	return result
+}
+
 // getCellCoords converts world position to grid coordinates
 func (sg *SpatialGrid) getCellCoords(position Vector2) (int, int) {
 	col := int(position.X / sg.cellSize)
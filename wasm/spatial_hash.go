@@ -0,0 +1,123 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// hashCell identifies a cell of the unbounded plane
+type hashCell struct {
+	col, row int
+}
+
+// SpatialHash is a sparse spatial partition keyed by cell coordinates.
+// Unlike SpatialGrid it has no bounds, and memory grows with the number of
+// occupied cells rather than with the world area.
+type SpatialHash struct {
+	cellSize float64
+	cells    map[hashCell][]int
+	points   []Vector2 // position of each inserted boid, by boid index
+	count    int
+}
+
+// NewSpatialHash creates an empty spatial hash
+func NewSpatialHash(cellSize float64) *SpatialHash {
+	return &SpatialHash{
+		cellSize: cellSize,
+		cells:    make(map[hashCell][]int),
+	}
+}
+
+// Clear resets all cells. Cells that stayed empty since the previous Clear
+// are dropped so a flock roaming the plane doesn't leave a trail of them.
+func (sh *SpatialHash) Clear() {
+	for key, cell := range sh.cells {
+		if len(cell) == 0 {
+			delete(sh.cells, key)
+			continue
+		}
+		sh.cells[key] = cell[:0] // reset slice but keep capacity
+	}
+	sh.count = 0
+}
+
+// Insert adds a boid to the hash
+func (sh *SpatialHash) Insert(boidIndex int, position Vector2) {
+	key := sh.getCell(position)
+	sh.cells[key] = append(sh.cells[key], boidIndex)
+	sh.points = setPoint(sh.points, boidIndex, position)
+	sh.count++
+}
+
+// Build is a no-op; the hash is always ready to query
+func (sh *SpatialHash) Build() {}
+
+// GetNeighbors returns all boid indices in cells within the given radius
+func (sh *SpatialHash) GetNeighbors(position Vector2, radius float64) []int {
+	return sh.QueryRect(Rect{
+		X:      position.X - radius,
+		Y:      position.Y - radius,
+		Width:  2 * radius,
+		Height: 2 * radius,
+	})
+}
+
+// QueryRadius returns the boid indices strictly within radius
+func (sh *SpatialHash) QueryRadius(position Vector2, radius float64) []int {
+	return filterWithinRadius(sh.GetNeighbors(position, radius), sh.points, position, radius)
+}
+
+// KNearest returns up to k boid indices ordered by increasing distance
+func (sh *SpatialHash) KNearest(position Vector2, k int) []int {
+	return kNearestByExpansion(func(radius float64) []int {
+		return sh.QueryRadius(position, radius)
+	}, sh.points, position, k, sh.count, sh.cellSize)
+}
+
+// QueryRect returns all boid indices in cells overlapping the rectangle
+func (sh *SpatialHash) QueryRect(rect Rect) []int {
+	result := make([]int, 0, 64)
+
+	minCell := sh.getCell(Vector2{X: rect.X, Y: rect.Y})
+	maxCell := sh.getCell(Vector2{X: rect.X + rect.Width, Y: rect.Y + rect.Height})
+	spanned := (maxCell.col - minCell.col + 1) * (maxCell.row - minCell.row + 1)
+
+	// For a large rectangle over a sparse plane, walking the occupied cells is
+	// cheaper. Map order is random, so the matches are sorted into the same
+	// row-major order as the dense walk to keep force sums reproducible.
+	if spanned > len(sh.cells) {
+		var keys []hashCell
+		for key := range sh.cells {
+			if key.col >= minCell.col && key.col <= maxCell.col && key.row >= minCell.row && key.row <= maxCell.row {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(a, b int) bool {
+			if keys[a].row != keys[b].row {
+				return keys[a].row < keys[b].row
+			}
+			return keys[a].col < keys[b].col
+		})
+		for _, key := range keys {
+			result = append(result, sh.cells[key]...)
+		}
+		return result
+	}
+
+	for row := minCell.row; row <= maxCell.row; row++ {
+		for col := minCell.col; col <= maxCell.col; col++ {
+			result = append(result, sh.cells[hashCell{col: col, row: row}]...)
+		}
+	}
+
+	return result
+}
+
+// getCell converts a world position to cell coordinates, flooring so that
+// negative coordinates map to their own cells
+func (sh *SpatialHash) getCell(position Vector2) hashCell {
+	return hashCell{
+		col: int(math.Floor(position.X / sh.cellSize)),
+		row: int(math.Floor(position.Y / sh.cellSize)),
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSpatialHashNegativeCoordinates(t *testing.T) {
+	sh := NewSpatialHash(50.0)
+	sh.Insert(0, Vector2{X: -10.0, Y: -10.0})
+	sh.Insert(1, Vector2{X: 10.0, Y: 10.0})
+	sh.Insert(2, Vector2{X: -5000.0, Y: 7000.0})
+
+	// -10 and 10 must land in different cells
+	if cell := sh.getCell(Vector2{X: -10.0, Y: -10.0}); cell != (hashCell{col: -1, row: -1}) {
+		t.Errorf("getCell(-10, -10) = %v, want {-1 -1}", cell)
+	}
+
+	neighbors := sh.GetNeighbors(Vector2{X: -10.0, Y: -10.0}, 20.0)
+	sort.Ints(neighbors)
+	if len(neighbors) != 2 || neighbors[0] != 0 || neighbors[1] != 1 {
+		t.Errorf("GetNeighbors() = %v, want [0 1]", neighbors)
+	}
+
+	far := sh.QueryRect(Rect{X: -5100.0, Y: 6900.0, Width: 200.0, Height: 200.0})
+	if len(far) != 1 || far[0] != 2 {
+		t.Errorf("QueryRect() = %v, want [2]", far)
+	}
+}
+
+func TestSpatialHashClearDropsEmptyCells(t *testing.T) {
+	sh := NewSpatialHash(50.0)
+	sh.Insert(0, Vector2{X: 0.0, Y: 0.0})
+
+	sh.Clear()
+	sh.Insert(0, Vector2{X: 1000.0, Y: 0.0})
+	sh.Clear()
+
+	// The cell at the origin has been empty for a whole frame
+	if len(sh.cells) != 1 {
+		t.Errorf("cell count = %d, want 1", len(sh.cells))
+	}
+}
+
+func TestSpatialHashMatchesGrid(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	grid := NewSpatialGrid(800.0, 600.0, 75.0)
+	hash := NewSpatialHash(75.0)
+
+	positions := make([]Vector2, 500)
+	for i := range positions {
+		positions[i] = Vector2{X: rng.Float64() * 800.0, Y: rng.Float64() * 600.0}
+		grid.Insert(i, positions[i])
+		hash.Insert(i, positions[i])
+	}
+
+	// Both indices must yield the same neighbors once filtered by distance
+	for _, center := range positions[:50] {
+		want := withinRadius(grid.GetNeighbors(center, 50.0), positions, center, 50.0)
+		got := withinRadius(hash.GetNeighbors(center, 50.0), positions, center, 50.0)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("neighbors of %v = %v, want %v", center, got, want)
+		}
+	}
+}
+
+// withinRadius filters candidate indices down to those inside the radius, sorted
+func withinRadius(candidates []int, positions []Vector2, center Vector2, radius float64) []int {
+	result := make([]int, 0, len(candidates))
+	for _, index := range candidates {
+		if positions[index].DistanceSquared(center) < radius*radius {
+			result = append(result, index)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+func TestSpatialHashQueryRectOrder(t *testing.T) {
+	sh := NewSpatialHash(50.0)
+	sh.Insert(0, Vector2{X: 160.0, Y: 60.0}) // cell {3 1}
+	sh.Insert(1, Vector2{X: 10.0, Y: 60.0})  // cell {0 1}
+	sh.Insert(2, Vector2{X: 110.0, Y: 10.0}) // cell {2 0}
+
+	// The rectangle spans more cells than are occupied, so the sparse walk is
+	// used; it must return the dense row-major order on every call
+	for i := 0; i < 20; i++ {
+		got := sh.QueryRect(Rect{X: 0.0, Y: 0.0, Width: 500.0, Height: 500.0})
+		if fmt.Sprint(got) != "[2 1 0]" {
+			t.Fatalf("QueryRect() = %v, want [2 1 0]", got)
+		}
+	}
+}
+
+func TestSpatialHashStepsAreReproducible(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulation(0, 800.0, 600.0)
+	if err := changeSpatialIndex(IndexHash); err != nil {
+		t.Fatalf("changeSpatialIndex(hash) error = %v", err)
+	}
+
+	// One tight flock occupies fewer cells than a neighbor query spans, so
+	// queries take the sparse walk over the occupied cells
+	rng := rand.New(rand.NewSource(5))
+	initial := make([]Boid, 60)
+	for i := range initial {
+		initial[i] = NewBoid(400.0+rng.Float64()*40.0, 300.0+rng.Float64()*40.0)
+		initial[i].Velocity = Vector2{X: rng.Float64()*2 - 1, Y: rng.Float64()*2 - 1}
+	}
+
+	first := runFlock(initial, 1, 200)
+	for run := 0; run < 4; run++ {
+		again := runFlock(initial, 1, 200)
+		for i := range first {
+			if again[i] != first[i] {
+				t.Fatalf("run %d boid %d = %+v, want %+v", run+2, i, again[i], first[i])
+			}
+		}
+	}
+
+	// Reset global state
+	forceWorkers = defaultForceWorkers
+	spatialIndexKind = IndexGrid
+	spatialGrid = nil
+	stepCount = 0
+	loadBoids(nil)
+	params = SimulationParams{}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// SpatialIndex is the neighbor lookup contract shared by every spatial partition.
+// Indices refer to positions in the boids slice at the time of insertion.
+type SpatialIndex interface {
+	// Clear removes every boid from the index
+	Clear()
+	// Insert adds a boid at the given position
+	Insert(boidIndex int, position Vector2)
+	// Build finishes any deferred work after a batch of inserts. Queries call
+	// it lazily, but calling it up front makes concurrent queries safe.
+	Build()
+	// GetNeighbors returns candidate boids that may lie within radius; callers
+	// still filter by exact distance
+	GetNeighbors(position Vector2, radius float64) []int
+	// QueryRadius returns the boids strictly within radius of position
+	QueryRadius(position Vector2, radius float64) []int
+	// KNearest returns up to k boids ordered by increasing distance from position
+	KNearest(position Vector2, k int) []int
+	// QueryRect returns candidate boids that may lie inside the rectangle
+	QueryRect(rect Rect) []int
+}
+
+// SpatialIndexKind selects the spatial partition used for neighbor searches
+type SpatialIndexKind string
+
+const (
+	IndexGrid     SpatialIndexKind = "grid"     // dense uniform grid (spatial hash in open sky)
+	IndexFlatGrid SpatialIndexKind = "flatgrid" // counting-sorted flat grid (spatial hash in open sky)
+	IndexHash     SpatialIndexKind = "hash"     // sparse hash of grid cells
+	IndexQuadtree SpatialIndexKind = "quadtree" // adaptive quadtree, robust to tight clusters
+	IndexKDTree   SpatialIndexKind = "kdtree"   // balanced 2-d tree
+)
+
+// newSpatialIndex creates the spatial index selected by spatialIndexKind
+func newSpatialIndex() SpatialIndex {
+	switch spatialIndexKind {
+	case IndexHash:
+		return NewSpatialHash(spatialCellSize)
+	case IndexQuadtree:
+		return NewQuadtree()
+	case IndexKDTree:
+		return NewKDTree()
+	case IndexFlatGrid:
+		if boundaryMode == BoundaryOpen {
+			return NewSpatialHash(spatialCellSize)
+		}
+		return NewFlatGrid(worldWidth, worldHeight, spatialCellSize)
+	default:
+		// A dense grid needs bounds, so the open sky falls back to hashing
+		if boundaryMode == BoundaryOpen {
+			return NewSpatialHash(spatialCellSize)
+		}
+		return NewSpatialGrid(worldWidth, worldHeight, spatialCellSize)
+	}
+}
+
+// changeSpatialIndex switches the spatial index used by the simulation
+func changeSpatialIndex(kind SpatialIndexKind) error {
+	switch kind {
+	case IndexGrid, IndexFlatGrid, IndexHash, IndexQuadtree, IndexKDTree:
+	default:
+		return fmt.Errorf("unknown spatial index %q", kind)
+	}
+
+	spatialIndexKind = kind
+	rebuildSpatialIndex()
+	return nil
+}
+
+// rebuildSpatialIndex replaces the spatial index after its kind or the world shape changed
+func rebuildSpatialIndex() {
+	spatialGrid = newSpatialIndex()
+	rebuildSpatialGrid()
+}
+
+// setPoint records the position of boidIndex, growing points as needed
+func setPoint(points []Vector2, boidIndex int, position Vector2) []Vector2 {
+	for len(points) <= boidIndex {
+		points = append(points, Vector2{})
+	}
+	points[boidIndex] = position
+	return points
+}
+
+// filterWithinRadius keeps only the candidates strictly within radius, reusing the slice
+func filterWithinRadius(candidates []int, points []Vector2, position Vector2, radius float64) []int {
+	radiusSquared := radius * radius
+	result := candidates[:0]
+	for _, index := range candidates {
+		if points[index].DistanceSquared(position) < radiusSquared {
+			result = append(result, index)
+		}
+	}
+	return result
+}
+
+// kNearestByExpansion answers a k-nearest query for cell-based indices by
+// doubling the search radius until at least k boids are inside it. Every boid
+// closer than the final radius has been seen, so the best k are exact.
+func kNearestByExpansion(query func(radius float64) []int, points []Vector2, position Vector2, k, total int, radius float64) []int {
+	if k <= 0 || total == 0 {
+		return nil
+	}
+	k = min(k, total)
+
+	for {
+		found := query(radius)
+		if len(found) >= k {
+			best := newNearestSet(k)
+			for _, index := range found {
+				best.offer(index, points[index].DistanceSquared(position))
+			}
+			return best.indices()
+		}
+		radius *= 2
+	}
+}
+
+// nearestItem is a candidate in a k-nearest search
+type nearestItem struct {
+	index    int
+	distance float64 // squared distance
+}
+
+// nearestSet keeps the k closest candidates seen so far, sorted by distance
+// with ties broken by index so results are deterministic
+type nearestSet struct {
+	k     int
+	items []nearestItem
+}
+
+func newNearestSet(k int) *nearestSet {
+	return &nearestSet{k: k, items: make([]nearestItem, 0, k+1)}
+}
+
+// offer adds a candidate if it is among the k closest
+func (s *nearestSet) offer(index int, distanceSquared float64) {
+	item := nearestItem{index: index, distance: distanceSquared}
+	if len(s.items) == s.k && !item.less(s.items[len(s.items)-1]) {
+		return
+	}
+
+	// Insertion sort: k is small for neighbor queries
+	pos := len(s.items)
+	for pos > 0 && item.less(s.items[pos-1]) {
+		pos--
+	}
+	s.items = append(s.items, nearestItem{})
+	copy(s.items[pos+1:], s.items[pos:])
+	s.items[pos] = item
+	if len(s.items) > s.k {
+		s.items = s.items[:s.k]
+	}
+}
+
+// worst returns the squared distance a candidate must beat, or +Inf while not full
+func (s *nearestSet) worst() float64 {
+	if len(s.items) < s.k {
+		return math.Inf(1)
+	}
+	return s.items[len(s.items)-1].distance
+}
+
+// indices returns the collected boid indices, closest first
+func (s *nearestSet) indices() []int {
+	result := make([]int, len(s.items))
+	for i, item := range s.items {
+		result[i] = item.index
+	}
+	return result
+}
+
+func (a nearestItem) less(b nearestItem) bool {
+	if a.distance != b.distance {
+		return a.distance < b.distance
+	}
+	return a.index < b.index
+}
+
+// boxDistanceSquared returns the squared distance from p to the box, zero when inside
+func boxDistanceSquared(p Vector2, minX, minY, maxX, maxY float64) float64 {
+	dx := math.Max(math.Max(minX-p.X, 0), p.X-maxX)
+	dy := math.Max(math.Max(minY-p.Y, 0), p.Y-maxY)
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// spatialIndexFactories builds one of each spatial index over an 800x600 world
+var spatialIndexFactories = []struct {
+	kind SpatialIndexKind
+	new  func() SpatialIndex
+}{
+	{IndexGrid, func() SpatialIndex { return NewSpatialGrid(800.0, 600.0, 75.0) }},
+	{IndexFlatGrid, func() SpatialIndex { return NewFlatGrid(800.0, 600.0, 75.0) }},
+	{IndexHash, func() SpatialIndex { return NewSpatialHash(75.0) }},
+	{IndexQuadtree, func() SpatialIndex { return NewQuadtree() }},
+	{IndexKDTree, func() SpatialIndex { return NewKDTree() }},
+}
+
+func TestSpatialIndexQueriesMatchBruteForce(t *testing.T) {
+	for _, distribution := range benchmarkDistributions {
+		positions := distribution.generate(rand.New(rand.NewSource(7)), 400, 800.0, 600.0)
+
+		for _, factory := range spatialIndexFactories {
+			t.Run(fmt.Sprintf("%s/%s", factory.kind, distribution.name), func(t *testing.T) {
+				index := factory.new()
+				// Insert twice to check Clear really empties the index
+				for i, p := range positions {
+					index.Insert(i, Vector2{X: p.Y, Y: p.X})
+				}
+				index.Clear()
+				for i, p := range positions {
+					index.Insert(i, p)
+				}
+
+				for _, center := range positions[:40] {
+					got := index.QueryRadius(center, 50.0)
+					sort.Ints(got)
+					want := bruteForceRadius(positions, center, 50.0)
+					if fmt.Sprint(got) != fmt.Sprint(want) {
+						t.Fatalf("QueryRadius(%v) = %v, want %v", center, got, want)
+					}
+
+					nearest := index.KNearest(center, 7)
+					wantNearest := bruteForceKNearest(positions, center, 7)
+					if fmt.Sprint(nearest) != fmt.Sprint(wantNearest) {
+						t.Fatalf("KNearest(%v) = %v, want %v", center, nearest, wantNearest)
+					}
+				}
+
+				rect := Rect{X: 200.0, Y: 150.0, Width: 300.0, Height: 200.0}
+				got := withinRect(index.QueryRect(rect), positions, rect)
+				want := withinRect(allIndices(len(positions)), positions, rect)
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("QueryRect() = %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestSpatialIndexEmptyAndStacked(t *testing.T) {
+	for _, factory := range spatialIndexFactories {
+		t.Run(string(factory.kind), func(t *testing.T) {
+			index := factory.new()
+			if got := index.KNearest(Vector2{X: 10.0, Y: 10.0}, 3); len(got) != 0 {
+				t.Errorf("KNearest() on empty index = %v, want none", got)
+			}
+
+			// Many boids on exactly the same spot must not break subdivision
+			for i := 0; i < 100; i++ {
+				index.Insert(i, Vector2{X: 300.0, Y: 300.0})
+			}
+			if got := index.QueryRadius(Vector2{X: 300.0, Y: 300.0}, 1.0); len(got) != 100 {
+				t.Errorf("QueryRadius() over stacked boids returned %d, want 100", len(got))
+			}
+			if got := index.KNearest(Vector2{X: 0.0, Y: 0.0}, 500); len(got) != 100 {
+				t.Errorf("KNearest(500) returned %d, want all 100", len(got))
+			}
+		})
+	}
+}
+
+func TestChangeSpatialIndex(t *testing.T) {
+	initSimulation(50, 800.0, 600.0)
+
+	for _, factory := range spatialIndexFactories {
+		if err := changeSpatialIndex(factory.kind); err != nil {
+			t.Fatalf("changeSpatialIndex(%s) error = %v", factory.kind, err)
+		}
+		if got := fmt.Sprintf("%T", spatialGrid); got != fmt.Sprintf("%T", factory.new()) {
+			t.Errorf("spatial index for %s = %s", factory.kind, got)
+		}
+		if got := spatialGrid.KNearest(flock.Position(0), 1); len(got) != 1 || got[0] != 0 {
+			t.Errorf("%s KNearest(boid 0) = %v, want [0]", factory.kind, got)
+		}
+	}
+
+	if err := changeSpatialIndex("octree"); err == nil {
+		t.Errorf("changeSpatialIndex() with unknown kind error = nil, want error")
+	}
+
+	// Reset global state
+	spatialIndexKind = IndexGrid
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestSelectNth(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	keys := make([]float64, 200)
+	for i := range keys {
+		keys[i] = float64(rng.Intn(20)) // plenty of duplicates
+	}
+	sorted := append([]float64(nil), keys...)
+	sort.Float64s(sorted)
+
+	for _, n := range []int{0, 57, 100, 199} {
+		items := allIndices(len(keys))
+		selectNth(items, n, func(i int) float64 { return keys[i] })
+		if keys[items[n]] != sorted[n] {
+			t.Errorf("selectNth(%d) = %v, want %v", n, keys[items[n]], sorted[n])
+		}
+		for i := range items {
+			if (i < n && keys[items[i]] > sorted[n]) || (i > n && keys[items[i]] < sorted[n]) {
+				t.Fatalf("selectNth(%d) left %v on the wrong side at %d", n, keys[items[i]], i)
+			}
+		}
+	}
+}
+
+// benchmarkDistributions are the boid layouts used to compare spatial indices
+var benchmarkDistributions = []struct {
+	name     string
+	generate func(rng *rand.Rand, count int, width, height float64) []Vector2
+}{
+	{"uniform", uniformPositions},
+	{"clustered", clusteredPositions},
+	{"milling", millingPositions},
+}
+
+// uniformPositions spreads boids evenly over the world
+func uniformPositions(rng *rand.Rand, count int, width, height float64) []Vector2 {
+	positions := make([]Vector2, count)
+	for i := range positions {
+		positions[i] = Vector2{X: rng.Float64() * width, Y: rng.Float64() * height}
+	}
+	return positions
+}
+
+// clusteredPositions packs boids into a few tight flocks
+func clusteredPositions(rng *rand.Rand, count int, width, height float64) []Vector2 {
+	centers := uniformPositions(rng, 5, width, height)
+	positions := make([]Vector2, count)
+	for i := range positions {
+		c := centers[i%len(centers)]
+		positions[i] = Vector2{X: c.X + rng.NormFloat64()*10.0, Y: c.Y + rng.NormFloat64()*10.0}
+	}
+	return positions
+}
+
+// millingPositions places boids on a ring, as in a milling (torus) formation
+func millingPositions(rng *rand.Rand, count int, width, height float64) []Vector2 {
+	center := Vector2{X: width / 2, Y: height / 2}
+	radius := math.Min(width, height) / 3
+	positions := make([]Vector2, count)
+	for i := range positions {
+		angle := rng.Float64() * 2 * math.Pi
+		r := radius + rng.NormFloat64()*radius*0.05
+		positions[i] = Vector2{X: center.X + r*math.Cos(angle), Y: center.Y + r*math.Sin(angle)}
+	}
+	return positions
+}
+
+func bruteForceRadius(positions []Vector2, center Vector2, radius float64) []int {
+	return withinRadius(allIndices(len(positions)), positions, center, radius)
+}
+
+func bruteForceKNearest(positions []Vector2, center Vector2, k int) []int {
+	best := newNearestSet(k)
+	for i, p := range positions {
+		best.offer(i, p.DistanceSquared(center))
+	}
+	return best.indices()
+}
+
+// withinRect filters candidate indices down to those inside the rectangle, sorted
+func withinRect(candidates []int, positions []Vector2, rect Rect) []int {
+	result := make([]int, 0, len(candidates))
+	for _, index := range candidates {
+		if rect.Contains(positions[index]) {
+			result = append(result, index)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+func allIndices(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+func BenchmarkSpatialIndex(b *testing.B) {
+	const width, height = 4000.0, 3000.0
+	factories := map[SpatialIndexKind]func() SpatialIndex{
+		IndexGrid:     func() SpatialIndex { return NewSpatialGrid(width, height, 75.0) },
+		IndexFlatGrid: func() SpatialIndex { return NewFlatGrid(width, height, 75.0) },
+		IndexHash:     func() SpatialIndex { return NewSpatialHash(75.0) },
+		IndexQuadtree: func() SpatialIndex { return NewQuadtree() },
+		IndexKDTree:   func() SpatialIndex { return NewKDTree() },
+	}
+
+	for _, distribution := range benchmarkDistributions {
+		for _, count := range []int{1000, 10000} {
+			positions := distribution.generate(rand.New(rand.NewSource(1)), count, width, height)
+
+			for _, kind := range []SpatialIndexKind{IndexGrid, IndexFlatGrid, IndexHash, IndexQuadtree, IndexKDTree} {
+				index := factories[kind]()
+				b.Run(fmt.Sprintf("%s/%d/%s/radius", distribution.name, count, kind), func(b *testing.B) {
+					for n := 0; n < b.N; n++ {
+						index.Clear()
+						for i, p := range positions {
+							index.Insert(i, p)
+						}
+						for _, p := range positions {
+							index.QueryRadius(p, 50.0)
+						}
+					}
+				})
+				b.Run(fmt.Sprintf("%s/%d/%s/knearest", distribution.name, count, kind), func(b *testing.B) {
+					for n := 0; n < b.N; n++ {
+						index.Clear()
+						for i, p := range positions {
+							index.Insert(i, p)
+						}
+						for _, p := range positions {
+							index.KNearest(p, 7)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestGridsIndexTheFarEdge(t *testing.T) {
+	for _, index := range []SpatialIndex{NewSpatialGrid(750.0, 600.0, 75.0), NewFlatGrid(750.0, 600.0, 75.0)} {
+		index.Insert(0, Vector2{X: 750.0, Y: 600.0})
+		index.Insert(1, Vector2{X: 100.0, Y: 100.0})
+		index.Build()
+
+		if got := index.QueryRadius(Vector2{X: 745.0, Y: 595.0}, 10.0); len(got) != 1 || got[0] != 0 {
+			t.Errorf("%T QueryRadius() at the far corner = %v, want [0]", index, got)
+		}
+		if got := index.KNearest(Vector2{X: 0, Y: 0}, 2); len(got) != 2 {
+			t.Errorf("%T KNearest() = %v, want both boids", index, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// StepStats is one row of the statistics history
+type StepStats struct {
+	Step          int
+	Count         int
+	MeanSpeed     float64
+	Polarization  float64
+	MeanNeighbors float64 // alignment neighbors per boid whose forces were recomputed
+	Clusters      int     // flock count from the latest detection, -1 before the first
+}
+
+// statsFields names the columns of the flat history export, in order
+var statsFields = []string{"step", "count", "meanSpeed", "polarization", "meanNeighbors", "clusters"}
+
+// statsRing keeps the most recent StepStats in a fixed-size ring
+type statsRing struct {
+	records []StepStats
+	next    int  // slot the next record goes to
+	full    bool // every slot holds a record
+}
+
+// statsHistory records per-step statistics; zero length disables it
+var statsHistory statsRing
+
+// enabled reports whether the ring has room for any records
+func (r *statsRing) enabled() bool {
+	return len(r.records) > 0
+}
+
+// push stores a record, overwriting the oldest when full
+func (r *statsRing) push(s StepStats) {
+	if !r.enabled() {
+		return
+	}
+	r.records[r.next] = s
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// since returns the records with Step >= sinceStep, oldest first
+func (r *statsRing) since(sinceStep int) []StepStats {
+	var ordered []StepStats
+	if r.full {
+		ordered = append(ordered, r.records[r.next:]...)
+	}
+	ordered = append(ordered, r.records[:r.next]...)
+
+	for i, s := range ordered {
+		if s.Step >= sinceStep {
+			return ordered[i:]
+		}
+	}
+	return nil
+}
+
+// changeStatsHistoryLength resizes the history to length steps, clearing it
+func changeStatsHistoryLength(length int) error {
+	if length < 0 {
+		return fmt.Errorf("stats history length must not be negative, got %d", length)
+	}
+	statsHistory = statsRing{records: make([]StepStats, length)}
+	return nil
+}
+
+// recordStepStats appends the statistics of the step that just finished.
+// computed is how many boids had their forces, and neighbor counts, refreshed.
+func recordStepStats(computed int) {
+	if !statsHistory.enabled() {
+		return
+	}
+
+	s := StepStats{Step: stepCount, Count: len(boids), Clusters: -1}
+	if clusters.Sizes != nil {
+		s.Clusters = clusters.Count()
+	}
+	if computed > 0 {
+		s.MeanNeighbors = float64(stepAlignmentNeighbors) / float64(computed)
+	}
+
+	if len(boids) > 0 {
+		var heading Vector2
+		sumSpeed := 0.0
+		for i := 0; i < flock.Len(); i++ {
+			v := flock.Velocity(i)
+			speed := v.Magnitude()
+			sumSpeed += speed
+			if speed > 0 {
+				heading = heading.Add(v.Div(speed))
+			}
+		}
+		n := float64(len(boids))
+		s.MeanSpeed = sumSpeed / n
+		s.Polarization = heading.Magnitude() / n
+	}
+
+	statsHistory.push(s)
+}
+
+// downsampleStats averages consecutive records into at most maxPoints rows.
+// Each row keeps the step of its last record so charts stay aligned.
+func downsampleStats(records []StepStats, maxPoints int) []StepStats {
+	if maxPoints <= 0 || len(records) <= maxPoints {
+		return records
+	}
+
+	bucket := int(math.Ceil(float64(len(records)) / float64(maxPoints)))
+	result := make([]StepStats, 0, maxPoints)
+	for start := 0; start < len(records); start += bucket {
+		end := min(start+bucket, len(records))
+		var sum StepStats
+		for _, s := range records[start:end] {
+			sum.Count += s.Count
+			sum.MeanSpeed += s.MeanSpeed
+			sum.Polarization += s.Polarization
+			sum.MeanNeighbors += s.MeanNeighbors
+			sum.Clusters += s.Clusters
+		}
+
+		n := end - start
+		result = append(result, StepStats{
+			Step:          records[end-1].Step,
+			Count:         int(math.Round(float64(sum.Count) / float64(n))),
+			MeanSpeed:     sum.MeanSpeed / float64(n),
+			Polarization:  sum.Polarization / float64(n),
+			MeanNeighbors: sum.MeanNeighbors / float64(n),
+			Clusters:      int(math.Round(float64(sum.Clusters) / float64(n))),
+		})
+	}
+	return result
+}
+
+// flattenStats lays records out row by row in statsFields order
+func flattenStats(records []StepStats) []float64 {
+	flat := make([]float64, 0, len(records)*len(statsFields))
+	for _, s := range records {
+		flat = append(flat, float64(s.Step), float64(s.Count), s.MeanSpeed,
+			s.Polarization, s.MeanNeighbors, float64(s.Clusters))
+	}
+	return flat
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStatsRingKeepsLatest(t *testing.T) {
+	if err := changeStatsHistoryLength(3); err != nil {
+		t.Fatalf("changeStatsHistoryLength(3) error: %v", err)
+	}
+	for step := 1; step <= 5; step++ {
+		statsHistory.push(StepStats{Step: step})
+	}
+
+	tests := []struct {
+		since int
+		want  []int
+	}{
+		{0, []int{3, 4, 5}},
+		{4, []int{4, 5}},
+		{6, nil},
+	}
+	for _, tt := range tests {
+		got := statsHistory.since(tt.since)
+		var steps []int
+		for _, s := range got {
+			steps = append(steps, s.Step)
+		}
+		if len(steps) != len(tt.want) {
+			t.Errorf("since(%d) = %v, want %v", tt.since, steps, tt.want)
+			continue
+		}
+		for i := range steps {
+			if steps[i] != tt.want[i] {
+				t.Errorf("since(%d) = %v, want %v", tt.since, steps, tt.want)
+				break
+			}
+		}
+	}
+
+	if err := changeStatsHistoryLength(-1); err == nil {
+		t.Errorf("changeStatsHistoryLength(-1) error = nil, want error")
+	}
+
+	// Reset global state
+	statsHistory = statsRing{}
+}
+
+func TestDownsampleStatsAveragesBuckets(t *testing.T) {
+	var records []StepStats
+	for step := 1; step <= 10; step++ {
+		records = append(records, StepStats{Step: step, Count: 100, MeanSpeed: float64(step)})
+	}
+
+	got := downsampleStats(records, 4)
+
+	// Buckets of three: steps 1-3, 4-6, 7-9, 10
+	wantSteps := []int{3, 6, 9, 10}
+	wantSpeeds := []float64{2, 5, 8, 10}
+	if len(got) != len(wantSteps) {
+		t.Fatalf("downsampleStats() returned %d rows, want %d", len(got), len(wantSteps))
+	}
+	for i := range got {
+		if got[i].Step != wantSteps[i] || got[i].MeanSpeed != wantSpeeds[i] || got[i].Count != 100 {
+			t.Errorf("row %d = %+v, want step %d speed %v count 100", i, got[i], wantSteps[i], wantSpeeds[i])
+		}
+	}
+
+	if got := downsampleStats(records, 0); len(got) != len(records) {
+		t.Errorf("downsampleStats(0) returned %d rows, want all %d", len(got), len(records))
+	}
+}
+
+func TestRecordStepStatsDuringSteps(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(37)), 500, 800.0, 600.0)
+	clusters = ClusterResult{}
+	changeStatsHistoryLength(100)
+	stepCount = 0
+
+	for i := 0; i < 4; i++ {
+		stepSimulation()
+	}
+	detectClusters()
+	stepSimulation()
+
+	records := statsHistory.since(0)
+	if len(records) != 5 {
+		t.Fatalf("history has %d records, want 5", len(records))
+	}
+	for i, s := range records {
+		if s.Step != i+1 || s.Count != 500 {
+			t.Errorf("record %d = %+v, want step %d with 500 boids", i, s, i+1)
+		}
+		if s.MeanSpeed <= 0 || s.Polarization < 0 || s.Polarization > 1 || s.MeanNeighbors <= 0 {
+			t.Errorf("record %d = %+v, want positive speed and neighbors, polarization in [0, 1]", i, s)
+		}
+	}
+	if records[0].Clusters != -1 || records[4].Clusters != clusters.Count() {
+		t.Errorf("cluster counts = %d then %d, want -1 then %d", records[0].Clusters, records[4].Clusters, clusters.Count())
+	}
+
+	if flat := flattenStats(records); len(flat) != 5*len(statsFields) || flat[len(statsFields)] != 2 {
+		t.Errorf("flattenStats() = %d values with second step %v, want %d values and 2", len(flat), flat[len(statsFields)], 5*len(statsFields))
+	}
+
+	// Reset global state
+	statsHistory = statsRing{}
+	clusters = ClusterResult{}
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
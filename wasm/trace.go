@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// defaultTraceEvents caps how many events a trace keeps before it stops
+// recording new steps, so a forgotten trace cannot exhaust memory
+const defaultTraceEvents = 200000
+
+// traceEvent is one record in the Chrome Trace Event Format
+type traceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	Ts   float64                `json:"ts"` // microseconds since the trace started
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// Tracer records the begin and end of every step phase for chrome://tracing
+// and Perfetto. While a tracer is active, force rules and integration run as
+// separate passes over the flock so each gets its own span; the arithmetic
+// is unchanged, so traced and untraced runs produce identical boids.
+type Tracer struct {
+	start        time.Time
+	events       []traceEvent
+	limit        int
+	skipping     bool // set for a whole step once the limit is reached
+	droppedSteps int
+}
+
+// tracer is the active tracer, nil when tracing is off
+var tracer *Tracer
+
+// startTracing starts a new trace keeping at most limit events
+func startTracing(limit int) {
+	if limit <= 0 {
+		limit = defaultTraceEvents
+	}
+	tracer = &Tracer{start: time.Now(), limit: limit}
+}
+
+// stopTracing ends the active trace and returns it, or nil when none was running
+func stopTracing() *Tracer {
+	t := tracer
+	tracer = nil
+	return t
+}
+
+// beginStep decides whether the coming step fits in the trace and opens its span.
+// Steps are kept or dropped whole so every begin has a matching end.
+func (t *Tracer) beginStep(step int) {
+	if t == nil {
+		return
+	}
+	// A step produces a few dozen events plus one per force batch and rule
+	t.skipping = len(t.events)+64+len(boids)/parallelChunkSize*8 > t.limit
+	if t.skipping {
+		t.droppedSteps++
+		return
+	}
+	t.record("step", "B", map[string]interface{}{"step": step, "boids": len(boids)})
+}
+
+// endStep closes the span opened by beginStep
+func (t *Tracer) endStep() {
+	t.end("step")
+}
+
+// begin opens a span named name
+func (t *Tracer) begin(name string) {
+	if t != nil && !t.skipping {
+		t.record(name, "B", nil)
+	}
+}
+
+// end closes the span named name
+func (t *Tracer) end(name string) {
+	if t != nil && !t.skipping {
+		t.record(name, "E", nil)
+	}
+}
+
+func (t *Tracer) record(name, ph string, args map[string]interface{}) {
+	t.events = append(t.events, traceEvent{
+		Name: name,
+		Cat:  "simulation",
+		Ph:   ph,
+		Ts:   float64(time.Since(t.start).Nanoseconds()) / 1000,
+		Pid:  1,
+		Tid:  1,
+		Args: args,
+	})
+}
+
+// WriteJSON writes the trace in the JSON Object Format of the Trace Event Format
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	events := t.events
+	if events == nil {
+		events = []traceEvent{}
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+		"otherData": map[string]interface{}{
+			"droppedSteps": t.droppedSteps,
+		},
+	})
+}
+
+// steeringRule is one term of the flocking force
+type steeringRule struct {
+	name  string
+	force func(b *Boid, boidIndex int, stats *neighborStats) Vector2
+}
+
+// steeringRules lists the terms in the order flockingForce adds them
+var steeringRules = []steeringRule{
+	{"separation", (*Boid).separate},
+	{"alignment", (*Boid).align},
+	{"cohesion", (*Boid).cohesion},
+	{"mouse", func(b *Boid, _ int, _ *neighborStats) Vector2 { return b.avoidMouse() }},
+}
+
+// computeForcePasses recomputes forces for size boids starting at begin,
+// one rule at a time so the tracer can time each rule separately
+func computeForcePasses(begin, size, n int) {
+	for r, rule := range steeringRules {
+		name := "force." + rule.name
+		tracer.begin(name)
+		parallelFor(size, forceWorkers, func(first, last int) {
+			stats := newNeighborStats()
+			for j := first; j < last; j++ {
+				i := (begin + j) % n
+				b := boidAt(i)
+				f := rule.force(&b, i, stats)
+				if r == 0 {
+					boids[i].Steering = f
+				} else {
+					boids[i].Steering = boids[i].Steering.Add(f)
+				}
+			}
+			stats.merge()
+		})
+		tracer.end(name)
+	}
+}
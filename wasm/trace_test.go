@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestTracedStepsMatchUntraced(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(17)), 1200, 800.0, 600.0)
+	initial := snapshotBoids()
+
+	untraced := runFlock(initial, 2, 5)
+	startTracing(0)
+	traced := runFlock(initial, 2, 5)
+	stopTracing()
+
+	for i := range untraced {
+		if traced[i] != untraced[i] {
+			t.Fatalf("traced boid %d = %+v, want %+v", i, traced[i], untraced[i])
+		}
+	}
+
+	// Reset global state
+	forceWorkers = defaultForceWorkers
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestTracerWritesBalancedEvents(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(19)), 600, 800.0, 600.0)
+
+	startTracing(0)
+	for i := 0; i < 3; i++ {
+		stepSimulation()
+	}
+	trace := stopTracing()
+	if tracer != nil {
+		t.Errorf("stopTracing() left tracer active")
+	}
+
+	var buf bytes.Buffer
+	if err := trace.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error: %v", err)
+	}
+	var decoded struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() produced invalid JSON: %v", err)
+	}
+
+	open := make(map[string]int)
+	seen := make(map[string]bool)
+	lastTs := 0.0
+	for _, event := range decoded.TraceEvents {
+		if event.Ts < lastTs {
+			t.Fatalf("event %q at %v precedes previous event at %v", event.Name, event.Ts, lastTs)
+		}
+		lastTs = event.Ts
+		seen[event.Name] = true
+		switch event.Ph {
+		case "B":
+			open[event.Name]++
+		case "E":
+			open[event.Name]--
+			if open[event.Name] < 0 {
+				t.Fatalf("event %q ends before it begins", event.Name)
+			}
+		}
+	}
+	for name, depth := range open {
+		if depth != 0 {
+			t.Errorf("event %q left %d spans open", name, depth)
+		}
+	}
+
+	for _, name := range []string{"step", "grid.Clear", "grid.Insert", "force.separation",
+		"force.alignment", "force.cohesion", "Update", "WrapAround"} {
+		if !seen[name] {
+			t.Errorf("trace has no %q events", name)
+		}
+	}
+
+	// Reset global state
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
+
+func TestTracerDropsWholeStepsAtLimit(t *testing.T) {
+	params = DefaultSimulationParams()
+	initSimulationFrom(rand.New(rand.NewSource(23)), 100, 800.0, 600.0)
+
+	startTracing(100)
+	for i := 0; i < 10; i++ {
+		stepSimulation()
+	}
+	trace := stopTracing()
+
+	if trace.droppedSteps == 0 {
+		t.Errorf("droppedSteps = 0, want steps dropped once the limit is reached")
+	}
+	if len(trace.events) > 100 {
+		t.Errorf("trace kept %d events, want at most 100", len(trace.events))
+	}
+
+	// Reset global state
+	stepCount = 0
+	loadBoids(nil)
+	spatialGrid = nil
+	params = SimulationParams{}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxTrailLength bounds how many positions each boid keeps
+const maxTrailLength = 1024
+
+// trailRing is the recent path of one boid, oldest sample at next once full
+type trailRing struct {
+	points []Vector2
+	next   int  // slot the next sample goes to
+	full   bool // every slot holds a sample
+	seen   int  // step the boid was last recorded, to drop removed boids
+}
+
+// push stores a sample, overwriting the oldest when full
+func (r *trailRing) push(p Vector2) {
+	r.points[r.next] = p
+	r.next++
+	if r.next == len(r.points) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// last returns the newest sample
+func (r *trailRing) last() (Vector2, bool) {
+	if !r.full && r.next == 0 {
+		return Vector2{}, false
+	}
+	return r.points[(r.next+len(r.points)-1)%len(r.points)], true
+}
+
+// Trails keeps the last Length positions of every boid, keyed by ID so the
+// history follows boids through reorders, deletions and migrations
+type Trails struct {
+	Length int
+	rings  map[int]*trailRing
+}
+
+// trailBreak is stored between two samples that must not be joined,
+// such as either side of a wrap at the world border
+var trailBreak = Vector2{X: math.NaN(), Y: math.NaN()}
+
+// trails is the active history, nil when disabled
+var trails *Trails
+
+// NewTrails creates an empty history holding length positions per boid
+func NewTrails(length int) (*Trails, error) {
+	if length < 1 || length > maxTrailLength {
+		return nil, fmt.Errorf("trail length must be between 1 and %d, got %d", maxTrailLength, length)
+	}
+	return &Trails{Length: length, rings: make(map[int]*trailRing)}, nil
+}
+
+// record appends the current position of every boid. A jump of more than
+// half the world since the previous sample can only be a wrap, so a break
+// is stored in front of it.
+func (t *Trails) record() {
+	if t == nil {
+		return
+	}
+
+	for i := range boids {
+		id, p := boids[i].ID, flock.Position(i)
+		ring, ok := t.rings[id]
+		if !ok {
+			ring = &trailRing{points: make([]Vector2, t.Length)}
+			t.rings[id] = ring
+		}
+
+		if prev, ok := ring.last(); ok && !math.IsNaN(prev.X) {
+			if math.Abs(p.X-prev.X) > worldWidth/2 || math.Abs(p.Y-prev.Y) > worldHeight/2 {
+				ring.push(trailBreak)
+			}
+		}
+		ring.push(p)
+		ring.seen = stepCount
+	}
+
+	// Forget boids that are gone
+	if len(t.rings) > len(boids) {
+		for id, ring := range t.rings {
+			if ring.seen != stepCount {
+				delete(t.rings, id)
+			}
+		}
+	}
+}
+
+// Values returns Length x, y pairs per boid in the order of the boids slice,
+// oldest first. Slots not yet filled and breaks at wraps are NaN, so a
+// renderer lifts the pen at every NaN.
+func (t *Trails) Values() []float32 {
+	values := make([]float32, len(boids)*t.Length*2)
+	for i := range values {
+		values[i] = float32(math.NaN())
+	}
+
+	for i := range boids {
+		ring, ok := t.rings[boids[i].ID]
+		if !ok {
+			continue
+		}
+
+		// Right-align the samples so the newest is always in the last slot
+		var ordered []Vector2
+		if ring.full {
+			ordered = append(ordered, ring.points[ring.next:]...)
+		}
+		ordered = append(ordered, ring.points[:ring.next]...)
+		out := values[(i*t.Length+t.Length-len(ordered))*2:]
+		for j, p := range ordered {
+			out[j*2] = float32(p.X)
+			out[j*2+1] = float32(p.Y)
+		}
+	}
+	return values
+}
+
+// trailLength is the configured history length, 0 when trails are off
+func trailLength() int {
+	if trails == nil {
+		return 0
+	}
+	return trails.Length
+}
+
+// configureTrails starts a new history, or turns it off when length is 0
+func configureTrails(length int) error {
+	if length == 0 {
+		trails = nil
+		return nil
+	}
+
+	t, err := NewTrails(length)
+	if err != nil {
+		return err
+	}
+	trails = t
+	return nil
+}
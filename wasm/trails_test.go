@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrailsKeepRecentPositions(t *testing.T) {
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{{ID: 7}})
+
+	tr, err := NewTrails(3)
+	if err != nil {
+		t.Fatalf("NewTrails() error: %v", err)
+	}
+	for x := 1.0; x <= 4; x++ {
+		flock.SetPosition(0, Vector2{X: x * 10, Y: 5})
+		tr.record()
+	}
+
+	got := tr.Values()
+	want := []float32{20, 5, 30, 5, 40, 5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Values() = %v, want %v", got, want)
+		}
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestTrailsBreakAtWrap(t *testing.T) {
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{{ID: 1, Position: Vector2{X: 798, Y: 100}}})
+
+	tr, _ := NewTrails(4)
+	tr.record()
+	flock.SetPosition(0, Vector2{X: 2, Y: 100})
+	tr.record()
+
+	// Unfilled slot, last sample before the wrap, break, first sample after it
+	got := tr.Values()
+	if !math.IsNaN(float64(got[0])) || got[2] != 798 || !math.IsNaN(float64(got[4])) || got[6] != 2 {
+		t.Errorf("Values() = %v, want [NaN NaN 798 100 NaN NaN 2 100]", got)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+}
+
+func TestTrailsFollowBoidsByID(t *testing.T) {
+	worldWidth, worldHeight = 800.0, 600.0
+	loadBoids([]Boid{
+		{ID: 1, Position: Vector2{X: 10, Y: 10}},
+		{ID: 2, Position: Vector2{X: 20, Y: 20}},
+	})
+
+	tr, _ := NewTrails(2)
+	tr.record()
+
+	// Swap the boids and drop one; its history must follow the ID
+	stepCount++
+	loadBoids([]Boid{{ID: 2, Position: Vector2{X: 21, Y: 21}}})
+	tr.record()
+
+	if len(tr.rings) != 1 {
+		t.Errorf("kept %d trails, want 1", len(tr.rings))
+	}
+	got := tr.Values()
+	if got[0] != 20 || got[2] != 21 {
+		t.Errorf("Values() = %v, want [20 20 21 21]", got)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	stepCount = 0
+}
+
+func TestNewTrailsValidates(t *testing.T) {
+	for _, length := range []int{-1, 0, maxTrailLength + 1} {
+		if _, err := NewTrails(length); err == nil {
+			t.Errorf("NewTrails(%d) succeeded, want error", length)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ResizePolicy selects what happens to boid positions when the world is resized
+type ResizePolicy string
+
+const (
+	ResizeKeep  ResizePolicy = "keep"  // leave boids in place, clamping any now outside unless the sky is open
+	ResizeScale ResizePolicy = "scale" // scale positions proportionally to the new size
+	ResizeWrap  ResizePolicy = "wrap"  // wrap positions outside the new bounds around
+)
+
+// setWorldSize changes the world bounds without respawning the flock
+func setWorldSize(width, height float64, policy ResizePolicy) error {
+	if !(width > 0) || !(height > 0) {
+		return fmt.Errorf("world size must be positive, got %vx%v", width, height)
+	}
+
+	switch policy {
+	case ResizeKeep:
+		// Open sky has no bounds to clamp to
+		if boundaryMode == BoundaryOpen {
+			break
+		}
+		maxX, maxY := justInside(width), justInside(height)
+		for i := range boids {
+			p := flock.Position(i)
+			p.X = math.Min(math.Max(p.X, 0), maxX)
+			p.Y = math.Min(math.Max(p.Y, 0), maxY)
+			flock.SetPosition(i, p)
+		}
+	case ResizeScale:
+		scaleX := width / worldWidth
+		scaleY := height / worldHeight
+		for i := range boids {
+			p := flock.Position(i)
+			flock.SetPosition(i, Vector2{X: p.X * scaleX, Y: p.Y * scaleY})
+		}
+	case ResizeWrap:
+		for i := range boids {
+			p := flock.Position(i)
+			p.X = wrapCoordinate(p.X, width)
+			p.Y = wrapCoordinate(p.Y, height)
+			flock.SetPosition(i, p)
+		}
+	default:
+		return fmt.Errorf("unknown resize policy %q", policy)
+	}
+
+	worldWidth = width
+	worldHeight = height
+
+	rebuildSpatialIndex()
+	return nil
+}
+
+// BoundaryMode selects what happens at the edge of the world
+type BoundaryMode string
+
+const (
+	BoundaryWrap BoundaryMode = "wrap" // boids wrap around the world bounds
+	BoundaryOpen BoundaryMode = "open" // open sky: boids roam an unbounded plane
+)
+
+// changeBoundaryMode switches between a wrapped world and an unbounded plane
+func changeBoundaryMode(mode BoundaryMode) error {
+	switch mode {
+	case BoundaryWrap:
+		// Bring roaming boids back into the world before bounding it again
+		for i := range boids {
+			p := flock.Position(i)
+			p.X = wrapCoordinate(p.X, worldWidth)
+			p.Y = wrapCoordinate(p.Y, worldHeight)
+			flock.SetPosition(i, p)
+		}
+	case BoundaryOpen:
+	default:
+		return fmt.Errorf("unknown boundary mode %q", mode)
+	}
+
+	boundaryMode = mode
+	rebuildSpatialIndex()
+	return nil
+}
+
+// justInside returns the largest coordinate below size that survives the
+// storage precision, the far limit of the half-open world [0, size)
+func justInside(size float64) float64 {
+	if precision == Precision32 {
+		return float64(math.Nextafter32(float32(size), 0))
+	}
+	return math.Nextafter(size, 0)
+}
+
+// wrapCoordinate maps v into [0, size)
+func wrapCoordinate(v, size float64) float64 {
+	v = math.Mod(v, size)
+	if v < 0 {
+		v += size
+	}
+	return v
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResizeWorld(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ResizePolicy
+		initial  Vector2
+		expected Vector2
+	}{
+		{
+			name:     "keep inside",
+			policy:   ResizeKeep,
+			initial:  Vector2{X: 100.0, Y: 100.0},
+			expected: Vector2{X: 100.0, Y: 100.0},
+		},
+		{
+			name:     "keep clamps outside",
+			policy:   ResizeKeep,
+			initial:  Vector2{X: 700.0, Y: 500.0},
+			expected: Vector2{X: 400.0, Y: 300.0},
+		},
+		{
+			name:     "scale",
+			policy:   ResizeScale,
+			initial:  Vector2{X: 200.0, Y: 300.0},
+			expected: Vector2{X: 100.0, Y: 150.0},
+		},
+		{
+			name:     "wrap",
+			policy:   ResizeWrap,
+			initial:  Vector2{X: 500.0, Y: 350.0},
+			expected: Vector2{X: 100.0, Y: 50.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initSimulation(0, 800.0, 600.0)
+			boid := NewBoid(tt.initial.X, tt.initial.Y)
+			loadBoids([]Boid{boid})
+
+			if err := setWorldSize(400.0, 300.0, tt.policy); err != nil {
+				t.Fatalf("setWorldSize() error = %v", err)
+			}
+
+			p := flock.Position(0)
+			if math.Abs(p.X-tt.expected.X) > 1e-9 || math.Abs(p.Y-tt.expected.Y) > 1e-9 {
+				t.Errorf("position after resize = %v, want %v", p, tt.expected)
+			}
+			if p.X >= 400.0 || p.Y >= 300.0 {
+				t.Errorf("position after resize = %v, want inside [0, 400) x [0, 300)", p)
+			}
+			if flock.Velocity(0) != boid.Velocity {
+				t.Errorf("velocity changed from %v to %v", boid.Velocity, flock.Velocity(0))
+			}
+			if worldWidth != 400.0 || worldHeight != 300.0 {
+				t.Errorf("world size = (%v, %v), want (400, 300)", worldWidth, worldHeight)
+			}
+			grid := spatialGrid.(*SpatialGrid)
+			if grid.cols != 6 || grid.rows != 4 {
+				t.Errorf("grid size = %dx%d, want 6x4", grid.cols, grid.rows)
+			}
+		})
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	worldWidth, worldHeight = 800.0, 600.0
+}
+
+func TestResizeKeepOnCellBoundary(t *testing.T) {
+	initSimulation(0, 800.0, 600.0)
+	loadBoids([]Boid{NewBoid(790.0, 100.0), NewBoid(795.0, 120.0)})
+
+	// 750 is a whole number of cells, so x == 750 would be past the last column
+	if err := setWorldSize(750.0, 600.0, ResizeKeep); err != nil {
+		t.Fatalf("setWorldSize() error = %v", err)
+	}
+	if p := flock.Position(0); p.X >= 750.0 {
+		t.Errorf("position after resize = %v, want x below 750", p)
+	}
+	if visible := visibleBoidIndices(Rect{X: 700.0, Y: 50.0, Width: 100.0, Height: 100.0}); len(visible) != 2 {
+		t.Errorf("visibleBoidIndices() = %v, want both clamped boids", visible)
+	}
+	if _, _, ok := findNearestBoid(Vector2{X: 100.0, Y: 100.0}, 0); !ok {
+		t.Errorf("findNearestBoid() found nothing with two boids present")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	worldWidth, worldHeight = 800.0, 600.0
+}
+
+func TestResizeKeepOpenSky(t *testing.T) {
+	initSimulation(0, 800.0, 600.0)
+	loadBoids([]Boid{NewBoid(5000.0, -3000.0)})
+	if err := changeBoundaryMode(BoundaryOpen); err != nil {
+		t.Fatalf("changeBoundaryMode(open) error = %v", err)
+	}
+
+	if err := setWorldSize(400.0, 300.0, ResizeKeep); err != nil {
+		t.Fatalf("setWorldSize() error = %v", err)
+	}
+	if p := flock.Position(0); p != (Vector2{X: 5000.0, Y: -3000.0}) {
+		t.Errorf("position after resize in open sky = %v, want (5000, -3000)", p)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	boundaryMode = BoundaryWrap
+	worldWidth, worldHeight = 800.0, 600.0
+}
+
+func TestResizeWorldRejectsInvalid(t *testing.T) {
+	initSimulation(3, 800.0, 600.0)
+
+	if err := setWorldSize(0, 300.0, ResizeKeep); err == nil {
+		t.Errorf("setWorldSize() with zero width error = nil, want error")
+	}
+	if err := setWorldSize(400.0, 300.0, "stretch"); err == nil {
+		t.Errorf("setWorldSize() with unknown policy error = nil, want error")
+	}
+	if worldWidth != 800.0 || worldHeight != 600.0 {
+		t.Errorf("world size after rejected resize = (%v, %v), want (800, 600)", worldWidth, worldHeight)
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+}
+
+func TestChangeBoundaryMode(t *testing.T) {
+	initSimulation(0, 800.0, 600.0)
+	loadBoids([]Boid{NewBoid(100.0, 100.0)})
+
+	if err := changeBoundaryMode(BoundaryOpen); err != nil {
+		t.Fatalf("changeBoundaryMode(open) error = %v", err)
+	}
+	if _, ok := spatialGrid.(*SpatialHash); !ok {
+		t.Fatalf("spatial index = %T, want *SpatialHash in open mode", spatialGrid)
+	}
+
+	// Boids can leave the original world in open mode
+	flock.SetPosition(0, Vector2{X: -2500.0, Y: 1300.0})
+	rebuildSpatialGrid()
+	if neighbors := spatialGrid.GetNeighbors(flock.Position(0), 10.0); len(neighbors) != 1 {
+		t.Errorf("GetNeighbors() outside world = %v, want [0]", neighbors)
+	}
+
+	// Switching back wraps them into the world again
+	if err := changeBoundaryMode(BoundaryWrap); err != nil {
+		t.Fatalf("changeBoundaryMode(wrap) error = %v", err)
+	}
+	if _, ok := spatialGrid.(*SpatialGrid); !ok {
+		t.Errorf("spatial index = %T, want *SpatialGrid in wrap mode", spatialGrid)
+	}
+	if p := flock.Position(0); p != (Vector2{X: 700.0, Y: 100.0}) {
+		t.Errorf("position after wrap = %v, want (700, 100)", p)
+	}
+
+	if err := changeBoundaryMode("bounce"); err == nil {
+		t.Errorf("changeBoundaryMode() with unknown mode error = nil, want error")
+	}
+
+	// Reset global state
+	loadBoids(nil)
+	spatialGrid = nil
+	boundaryMode = BoundaryWrap
+}
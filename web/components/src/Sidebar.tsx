@@ -226,7 +226,7 @@ export function Sidebar({
                 label="半径"
                 value={parameters.cohesionRadius}
                 min={20}
-                max={150}
+                max={400}
                 step={10}
                 onChange={(value) => onParameterChange("cohesionRadius", value)}
                 color="text-green-400"
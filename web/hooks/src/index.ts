@@ -1,4 +1,4 @@
-export type { Boid, SimulationParameters } from "./types"
+export type { Boid, SimulationParameters, StepBudget, StepReport } from "./types"
 export { useBoidWasm } from "./useBoidWasm"
 export { usePerformanceMonitor } from "./usePerformanceMonitor"
 export { useSimulation } from "./useSimulation"
@@ -7,6 +7,8 @@ test("Boid型が正しく定義されている", () => {
     id: 1,
     position: { x: 100, y: 200 },
     velocity: { x: 1.5, y: -0.5 },
+    cluster: -1,
+    flock: -1,
   }
 
   expect(boid.id).toBe(1)
@@ -14,6 +16,7 @@ test("Boid型が正しく定義されている", () => {
   expect(boid.position.y).toBe(200)
   expect(boid.velocity.x).toBe(1.5)
   expect(boid.velocity.y).toBe(-0.5)
+  expect(boid.cluster).toBe(-1)
 })
 
 test("SimulationParameters型が正しく定義されている", () => {
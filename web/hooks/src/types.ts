@@ -2,6 +2,8 @@ export type Boid = {
   id: number
   position: { x: number; y: number }
   velocity: { x: number; y: number }
+  cluster: number // 群れのラベル（どの群れにも属さない場合は -1）
+  flock: number // フレームをまたいで維持される群れID（どの群れにも属さない場合は -1）
 }
 
 export type SimulationParameters = {
@@ -13,3 +15,17 @@ export type SimulationParameters = {
   cohesionStrength: number
   mouseAvoidanceDistance: number
 }
+
+// updateSimulationに渡す1フレームあたりの計算予算（省略した項目は無制限）
+export type StepBudget = {
+  timeMs?: number
+  maxBoids?: number
+}
+
+// 1フレームで力を再計算できたボイドの割合
+export type StepReport = {
+  fraction: number
+  computed: number
+  boidCount: number
+  elapsedMs: number
+}
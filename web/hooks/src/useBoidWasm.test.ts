@@ -9,8 +9,8 @@ test("WasmExports型が必要な関数を含んでいる", () => {
     setMousePosition: vi.fn(),
     getBoidCount: vi.fn(() => 100),
     getAllBoidData: vi.fn(() => [
-      { x: 10, y: 20, vx: 1, vy: 2 },
-      { x: 30, y: 40, vx: -1, vy: -2 },
+      { id: 0, x: 10, y: 20, vx: 1, vy: 2, cluster: 0, flock: 3 },
+      { id: 1, x: 30, y: 40, vx: -1, vy: -2, cluster: -1, flock: -1 },
     ]),
     updateSeparationParams: vi.fn(),
     updateAlignmentParams: vi.fn(),
@@ -28,10 +28,12 @@ test("WasmExports型が必要な関数を含んでいる", () => {
   // バッチAPIが正しい形式のデータを返すことを確認
   const boidData = mockWasm.getAllBoidData()
   expect(Array.isArray(boidData)).toBe(true)
+  expect(boidData[0]).toHaveProperty("id")
   expect(boidData[0]).toHaveProperty("x")
   expect(boidData[0]).toHaveProperty("y")
   expect(boidData[0]).toHaveProperty("vx")
   expect(boidData[0]).toHaveProperty("vy")
+  expect(boidData[0]).toHaveProperty("cluster")
 })
 
 test("getBoidCount関数が数値を返す", () => {
@@ -1,5 +1,5 @@
 import { useCallback, useEffect, useState } from "react"
-import type { Boid } from "./types"
+import type { Boid, StepBudget, StepReport } from "./types"
 
 declare global {
   interface Window {
@@ -8,10 +8,18 @@ declare global {
       importObject: WebAssembly.Imports
     }
     initializeSimulation: (count: number, width: number, height: number) => void
-    updateSimulation: () => void
+    updateSimulation: (budget?: number | StepBudget) => StepReport
     setMousePosition: (x: number, y: number) => void
     getBoidCount: () => number
-    getAllBoidData: () => Array<{ x: number; y: number; vx: number; vy: number }>
+    getAllBoidData: () => Array<{
+      id: number
+      x: number
+      y: number
+      vx: number
+      vy: number
+      cluster: number
+      flock: number
+    }>
     updateSeparationParams: (radius: number, strength: number) => void
     updateAlignmentParams: (radius: number, strength: number) => void
     updateCohesionParams: (radius: number, strength: number) => void
@@ -21,10 +29,18 @@ declare global {
 
 type WasmExports = {
   initializeSimulation: (count: number, width: number, height: number) => void
-  updateSimulation: () => void
+  updateSimulation: (budget?: number | StepBudget) => StepReport
   setMousePosition: (x: number, y: number) => void
   getBoidCount: () => number
-  getAllBoidData: () => Array<{ x: number; y: number; vx: number; vy: number }>
+  getAllBoidData: () => Array<{
+    id: number
+    x: number
+    y: number
+    vx: number
+    vy: number
+    cluster: number
+    flock: number
+  }>
   updateSeparationParams: (radius: number, strength: number) => void
   updateAlignmentParams: (radius: number, strength: number) => void
   updateCohesionParams: (radius: number, strength: number) => void
@@ -97,11 +113,15 @@ export function useBoidWasm() {
     [wasmModule]
   )
 
-  const updateSimulation = useCallback(() => {
-    if (wasmModule) {
-      wasmModule.updateSimulation()
-    }
-  }, [wasmModule])
+  const updateSimulation = useCallback(
+    (budget?: number | StepBudget): StepReport | undefined => {
+      if (wasmModule) {
+        return wasmModule.updateSimulation(budget)
+      }
+      return undefined
+    },
+    [wasmModule]
+  )
 
   const setMousePosition = useCallback(
     (x: number, y: number) => {
@@ -122,7 +142,7 @@ export function useBoidWasm() {
     for (let i = 0; i < boidDataArray.length; i++) {
       const data = boidDataArray[i]
       boids.push({
-        id: i,
+        id: data.id,
         position: {
           x: data.x,
           y: data.y,
@@ -131,6 +151,8 @@ export function useBoidWasm() {
           x: data.vx,
           y: data.vy,
         },
+        cluster: data.cluster,
+        flock: data.flock,
       })
     }
 
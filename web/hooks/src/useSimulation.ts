@@ -13,6 +13,9 @@ const DEFAULT_PARAMETERS: SimulationParameters = {
   mouseAvoidanceDistance: 100,
 }
 
+// 1フレームのシミュレーション更新に使う時間の上限（60FPSの約半分）
+const UPDATE_BUDGET = { timeMs: 8 }
+
 export function useSimulation() {
   const {
     wasmModule,
@@ -65,8 +68,9 @@ export function useSimulation() {
       performanceMonitor.startFrame()
 
       // シミュレーション更新
+      // 重いフレームでは一部のボイドだけ力を再計算し、FPSを保つ
       performanceMonitor.startUpdate()
-      updateSimulation()
+      updateSimulation(UPDATE_BUDGET)
       performanceMonitor.endUpdate()
 
       // レンダリング準備